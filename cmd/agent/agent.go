@@ -13,6 +13,11 @@ func NewAgentCmd() *cobra.Command {
 
 	cmd.AddCommand(newInitCmd())
 	cmd.AddCommand(newRunCmd())
+	cmd.AddCommand(newPsCmd())
+	cmd.AddCommand(newStopCmd())
+	cmd.AddCommand(newRestartCmd())
+	cmd.AddCommand(newLogsCmd())
+	cmd.AddCommand(newHistoryCmd())
 
 	return cmd
 }
\ No newline at end of file
@@ -0,0 +1,486 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Streams an agent log line can come from
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+	streamEvent  = "event"
+)
+
+// noRunID stands in for the run ID of log events that concern no single run
+const noRunID = "-"
+
+// backupTimeFormat names rotated logs so that they sort by age
+const backupTimeFormat = "20060102T150405.000"
+
+// maxLineLength is the longest line kept in memory while waiting for its end;
+// longer lines are split
+const maxLineLength = 64 * 1024
+
+// rotateOptions controls when a log is rotated and how long rotated logs are
+// kept
+type rotateOptions struct {
+	Path string
+	// MaxSize is the size in bytes at which the log is rotated
+	MaxSize int64
+	// MaxAge removes rotated logs older than this; zero keeps them
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated logs kept; zero keeps them all
+	MaxBackups int
+	// Compress gzips rotated logs
+	Compress bool
+}
+
+// rotatingFile is a log file that is renamed to <name>-<time><ext> once it
+// reaches MaxSize, with older rotated logs compressed and removed. It is safe
+// for concurrent use; each Write lands in one file.
+type rotatingFile struct {
+	opts rotateOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// gen counts the rotations, and rotated holds the file each earlier
+	// generation of the log was renamed to
+	gen     int
+	rotated map[int]string
+}
+
+// logPosition is a byte offset in one generation of a rotating log
+type logPosition struct {
+	gen    int
+	offset int64
+}
+
+// openRotatingFile opens the log for appending. Rotated logs past MaxAge are
+// removed right away, as an agent that logs little may never rotate again.
+func openRotatingFile(opts rotateOptions) (*rotatingFile, error) {
+	r := &rotatingFile{opts: opts, rotated: make(map[int]string)}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	if err := r.prune(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove old logs of %s: %v\n", opts.Path, err)
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.opts.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	_, n, err := r.writeAt(p)
+	return n, err
+}
+
+// writeAt writes p like Write and also returns the position it was written at
+func (r *rotatingFile) writeAt(p []byte) (logPosition, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return logPosition{gen: r.gen}, 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rotate %s: %v\n", r.opts.Path, err)
+		}
+	}
+
+	pos := logPosition{gen: r.gen, offset: r.size}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return pos, n, err
+}
+
+// locate returns the file that now holds a position: the log itself, or the
+// rotated log it was renamed to, which may since have been compressed or
+// removed
+func (r *rotatingFile) locate(pos logPosition) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pos.gen == r.gen {
+		return r.opts.Path
+	}
+	return r.rotated[pos.gen]
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// rotate renames the current log and starts a new one. The old log is
+// compressed and rotated logs beyond the limits are removed.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.opts.Path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.opts.Path, ext), time.Now().UTC().Format(backupTimeFormat), ext)
+	renameErr := os.Rename(r.opts.Path, backup)
+
+	// Keep logging even if the rename failed
+	if err := r.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	r.rotated[r.gen] = backup
+	r.gen++
+
+	if r.opts.Compress {
+		if err := compressFile(backup); err != nil {
+			return err
+		}
+		r.rotated[r.gen-1] = backup + ".gz"
+	}
+	return r.prune()
+}
+
+// backups returns the rotated logs, oldest first
+func (r *rotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(r.opts.Path)
+	prefix := filepath.Base(strings.TrimSuffix(r.opts.Path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(r.opts.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(r.opts.Path), name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// prune removes rotated logs older than MaxAge and all but the newest
+// MaxBackups
+func (r *rotatingFile) prune() error {
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		remove := r.opts.MaxBackups > 0 && i < len(backups)-r.opts.MaxBackups
+		if !remove && r.opts.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > r.opts.MaxAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(backup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces path with path.gz
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+// lineWriter prefixes every line written to it with the time, run ID and
+// stream before passing it on whole. Output without a trailing newline is
+// held back until the line ends or Flush is called.
+type lineWriter struct {
+	out    io.Writer
+	runID  string
+	stream string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func newLineWriter(out io.Writer, runID, stream string) *lineWriter {
+	return &lineWriter{out: out, runID: runID, stream: stream}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			if len(w.buf) < maxLineLength {
+				return len(p), nil
+			}
+			i = maxLineLength
+		} else {
+			i++
+		}
+		if err := w.writeLine(w.buf[:i]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[i:]
+	}
+}
+
+// Flush writes out a final line that has no newline
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *lineWriter) writeLine(line []byte) error {
+	line = bytes.TrimRight(line, "\r\n")
+	_, err := fmt.Fprintf(w.out, "%s %s %-6s %s\n", time.Now().Format("2006-01-02T15:04:05.000Z07:00"), w.runID, w.stream, line)
+	return err
+}
+
+// newRunID returns a short random ID that tells the output of one agent run
+// apart from others in the log
+func newRunID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+// agentLog captures the output of an agent's runs
+type agentLog struct {
+	name string
+	file *rotatingFile
+}
+
+// openAgentLog opens the log of an agent as configured in its project
+func openAgentLog(agentFile, name string, project *projectConfig) (*agentLog, error) {
+	opts, err := project.logOptions(agentFile, name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := openRotatingFile(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &agentLog{name: name, file: file}, nil
+}
+
+// Path returns the file the log is written to
+func (l *agentLog) Path() string {
+	return l.file.opts.Path
+}
+
+func (l *agentLog) Close() error {
+	return l.file.Close()
+}
+
+// event records something that happened to a run rather than its output. It
+// returns where in the log the event starts and ends.
+func (l *agentLog) event(runID, format string, args ...interface{}) (logPosition, logPosition) {
+	var line bytes.Buffer
+	w := newLineWriter(&line, runID, streamEvent)
+	fmt.Fprintf(w, format+"\n", args...)
+
+	start, n, _ := l.file.writeAt(line.Bytes())
+	end := start
+	end.offset += int64(n)
+	return start, end
+}
+
+// agentRun is one execution of an agent, with its output captured to the
+// agent's log and its outcome recorded in the agent's history
+type agentRun struct {
+	ID        string
+	agentFile string
+	cmd       *exec.Cmd
+	log       *agentLog
+	stdout    *lineWriter
+	stderr    *lineWriter
+	started   time.Time
+	logStart  logPosition
+
+	// trigger and schedule tell how the run was started
+	trigger  string
+	schedule string
+	// endStatus is set when the run is ended on purpose, such as on a
+	// timeout, and recorded whatever the agent's exit status
+	endStatus atomic.Pointer[string]
+}
+
+// newRun prepares a run of the agent. Its output is also copied to stdout and
+// stderr when they are not nil.
+func (l *agentLog) newRun(agentFile string, stdout, stderr io.Writer) *agentRun {
+	r := &agentRun{ID: newRunID(), agentFile: agentFile, log: l, trigger: triggerManual}
+	r.cmd = createAgentCommand(agentFile, r.ID)
+	r.stdout = newLineWriter(l.file, r.ID, streamStdout)
+	r.stderr = newLineWriter(l.file, r.ID, streamStderr)
+	r.cmd.Stdout = tee(r.stdout, stdout)
+	r.cmd.Stderr = tee(r.stderr, stderr)
+	return r
+}
+
+func (r *agentRun) start() error {
+	r.started = time.Now()
+	if err := r.cmd.Start(); err != nil {
+		start, end := r.log.event(r.ID, "failed to start %s: %v", r.cmd.Path, err)
+		r.logStart = start
+		r.record(err, end)
+		return err
+	}
+	r.logStart, _ = r.log.event(r.ID, "started %s (pid %d)", strings.Join(r.cmd.Args, " "), r.cmd.Process.Pid)
+	return nil
+}
+
+// wait waits for the run to end and records how it ended
+func (r *agentRun) wait() error {
+	err := r.cmd.Wait()
+	r.stdout.Flush()
+	r.stderr.Flush()
+	_, end := r.log.event(r.ID, "%s after %s", describeExit(err), r.uptime())
+	r.record(err, end)
+	return err
+}
+
+// endAs marks the run as ended on purpose, with the status to record. The
+// first reason given is kept.
+func (r *agentRun) endAs(status string) {
+	r.endStatus.CompareAndSwap(nil, &status)
+}
+
+// record adds the run to the agent's history. Failing to do so does not fail
+// the run.
+func (r *agentRun) record(err error, logEnd logPosition) {
+	ended := time.Now()
+	record := &runRecord{
+		ID:              r.ID,
+		Agent:           r.log.name,
+		AgentFile:       r.agentFile,
+		Trigger:         r.trigger,
+		Schedule:        r.schedule,
+		Status:          runSucceeded,
+		StartedAt:       r.started,
+		EndedAt:         ended,
+		DurationSeconds: ended.Sub(r.started).Seconds(),
+		LogFile:         r.log.Path(),
+		LogStartFile:    r.log.file.locate(r.logStart),
+		LogStart:        r.logStart.offset,
+		LogEndFile:      r.log.file.locate(logEnd),
+		LogEnd:          logEnd.offset,
+	}
+
+	if err != nil {
+		record.Status = runFailed
+		record.ExitCode = -1
+		record.Error = err.Error()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			record.ExitCode = exitErr.ExitCode()
+		}
+	}
+	if status := r.endStatus.Load(); status != nil {
+		record.Status = *status
+	}
+
+	if err := appendHistory(record); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run %s: %v\n", r.ID, err)
+	}
+}
+
+func (r *agentRun) run() error {
+	if err := r.start(); err != nil {
+		return err
+	}
+	return r.wait()
+}
+
+func (r *agentRun) uptime() time.Duration {
+	return time.Since(r.started).Round(time.Millisecond)
+}
+
+// tee writes to log and, if it is set, to console
+func tee(log, console io.Writer) io.Writer {
+	if console == nil {
+		return log
+	}
+	return io.MultiWriter(log, console)
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestOpenRotatingFilePrunes(t *testing.T) {
+	now := time.Now()
+	ages := []time.Duration{30 * 24 * time.Hour, 10 * 24 * time.Hour, 2 * 24 * time.Hour, time.Hour}
+
+	tests := []struct {
+		name       string
+		maxAge     time.Duration
+		maxBackups int
+		// want are the indexes into ages of the backups left
+		want []int
+	}{
+		{name: "expired backups", maxAge: 7 * 24 * time.Hour, want: []int{2, 3}},
+		{name: "too many backups", maxBackups: 1, want: []int{3}},
+		{name: "both limits", maxAge: 7 * 24 * time.Hour, maxBackups: 3, want: []int{2, 3}},
+		{name: "no limits", want: []int{0, 1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "agent.log")
+
+			var backups []string
+			for _, age := range ages {
+				stamp := now.Add(-age).UTC()
+				backup := filepath.Join(dir, "agent-"+stamp.Format(backupTimeFormat)+".log.gz")
+				if err := os.WriteFile(backup, []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(backup, stamp, stamp); err != nil {
+					t.Fatal(err)
+				}
+				backups = append(backups, backup)
+			}
+
+			f, err := openRotatingFile(rotateOptions{Path: path, MaxSize: 1 << 20, MaxAge: tt.maxAge, MaxBackups: tt.maxBackups})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			left, _ := filepath.Glob(filepath.Join(dir, "agent-*"))
+			var want []string
+			for _, i := range tt.want {
+				want = append(want, backups[i])
+			}
+			sort.Strings(left)
+			sort.Strings(want)
+			if len(left) != len(want) {
+				t.Fatalf("left %v, want %v", left, want)
+			}
+			for i := range left {
+				if left[i] != want[i] {
+					t.Errorf("left %v, want %v", left, want)
+					break
+				}
+			}
+		})
+	}
+}
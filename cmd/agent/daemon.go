@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// superviseFlag marks the background process started by 'agent run --daemon',
+// which supervises the agent instead of starting another background process
+const superviseFlag = "supervise"
+
+// startTimeout is how long 'agent run --daemon' waits for the background
+// process to record its state
+const startTimeout = 5 * time.Second
+
+// resolveAgentName picks the name an agent is tracked under: --name, then
+// the name in the project's .aphelion/config.yaml, then the file name
+func resolveAgentName(agentFile string, project *projectConfig, name string) (string, error) {
+	hint := ""
+	if name == "" {
+		hint = ", or pass --name"
+		name = project.Name
+	}
+	if name == "" {
+		base := filepath.Base(agentFile)
+		name = strings.ToLower(strings.ReplaceAll(strings.TrimSuffix(base, filepath.Ext(base)), " ", "-"))
+	}
+
+	if !agentNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid agent name %q: use lowercase letters, digits, '.', '-' and '_'%s", name, hint)
+	}
+	return name, nil
+}
+
+// startDetached starts the CLI again in the background, in a session of its
+// own with output going to the supervisor log, to run command from dir as the
+// supervisor of the named agent. It returns once the background process has
+// recorded its state.
+func startDetached(name string, command []string, dir string) error {
+	if state, err := loadState(name); err == nil && state.running() {
+		return fmt.Errorf("agent %s is already running (pid %d); stop it with 'aphelion agent stop %s'", name, state.PID, name)
+	}
+
+	stateDir, err := agentDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	logPath := filepath.Join(stateDir, supervisorLogFile)
+	log, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open agent log: %w", err)
+	}
+	defer log.Close()
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the aphelion executable: %w", err)
+	}
+
+	child := exec.Command(self, append(append([]string{}, command...), "--"+superviseFlag)...)
+	child.Dir = dir
+	child.Stdout = log
+	child.Stderr = log
+	detachProcess(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start agent in the background: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(startTimeout)
+
+	for {
+		select {
+		case err := <-exited:
+			state, loadErr := loadState(name)
+			if loadErr != nil || state.PID != child.Process.Pid {
+				return fmt.Errorf("agent %s %s before it started; see %s", name, describeExit(err), logPath)
+			}
+			if state.Error != "" {
+				return fmt.Errorf("agent %s stopped: %s; see %s", name, state.Error, logPath)
+			}
+			fmt.Printf("✅ Agent %s ran in the background and has already exited\n", name)
+			fmt.Printf("   Logs: aphelion agent logs %s\n", name)
+			return nil
+		case <-timeout:
+			return fmt.Errorf("agent %s did not start within %s; see %s", name, startTimeout, logPath)
+		case <-ticker.C:
+			if state, err := loadState(name); err == nil && state.PID == child.Process.Pid {
+				fmt.Printf("✅ Agent %s started in the background (pid %d)\n", name, state.PID)
+				fmt.Printf("   Logs: aphelion agent logs %s -f\n", name)
+				fmt.Printf("   Stop: aphelion agent stop %s\n", name)
+				return nil
+			}
+		}
+	}
+}
+
+// runSupervised runs in the background process started by startDetached. It
+// records the agent's state for 'agent ps', 'stop', 'restart' and 'logs' while
+// run supervises the agent, reporting each agent process it starts.
+func runSupervised(name, agentFile, logPath string, gracePeriod time.Duration, run func(onStart func(pid, restarts int)) error) error {
+	stateDir, err := agentDir(name)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var command []string
+	for _, arg := range os.Args[1:] {
+		if arg != "--"+superviseFlag {
+			command = append(command, arg)
+		}
+	}
+
+	pidStart, _ := processStart(os.Getpid())
+	state := &agentState{
+		Name:          name,
+		PID:           os.Getpid(),
+		PIDStart:      pidStart,
+		AgentFile:     agentFile,
+		Dir:           cwd,
+		Command:       command,
+		LogFile:       logPath,
+		SupervisorLog: filepath.Join(stateDir, supervisorLogFile),
+		GracePeriod:   gracePeriod,
+		StartedAt:     time.Now(),
+	}
+	if err := state.save(); err != nil {
+		return err
+	}
+	if err := state.writePID(); err != nil {
+		return fmt.Errorf("failed to write pid file: %w", err)
+	}
+	defer state.removePID()
+
+	runErr := run(func(pid, restarts int) {
+		state.AgentPID = pid
+		state.AgentPIDStart, _ = processStart(pid)
+		state.Restarts = restarts
+		if err := state.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+
+	stopped := time.Now()
+	state.StoppedAt = &stopped
+	state.AgentPID, state.AgentPIDStart = 0, ""
+	if runErr != nil {
+		state.Error = runErr.Error()
+	}
+	if err := state.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return runErr
+}
@@ -0,0 +1,369 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+const (
+	// historyFile records an agent's runs, one JSON object per line
+	historyFile = "history.jsonl"
+	// historyMaxSize is the size at which historyFile is rotated to
+	// history.1.jsonl, shifting older files up to history.<historyBackups>.jsonl
+	historyMaxSize = 5 << 20
+	historyBackups = 9
+)
+
+// Ways a run is started
+const (
+	triggerManual = "manual"
+	triggerCron   = "cron"
+	triggerDaemon = "daemon"
+)
+
+// Run outcomes recorded in the history
+const (
+	runSucceeded = "succeeded"
+	runFailed    = "failed"
+	runTimedOut  = "timed-out"
+	runStopped   = "stopped"
+)
+
+var runStatuses = []string{runSucceeded, runFailed, runTimedOut, runStopped}
+
+// runRecord is the history entry of one agent run. LogStart and LogEnd are
+// the byte offsets of the run's first and last event in LogStartFile and
+// LogEndFile. These are LogFile unless the log was rotated during the run, in
+// which case they name the rotated log and the offset is into its
+// uncompressed content. A log rotated after the run ended becomes the first
+// rotated log stamped later than EndedAt.
+type runRecord struct {
+	ID              string    `json:"id" yaml:"id"`
+	Agent           string    `json:"agent" yaml:"agent"`
+	AgentFile       string    `json:"agent_file" yaml:"agent_file"`
+	Trigger         string    `json:"trigger" yaml:"trigger"`
+	Schedule        string    `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Status          string    `json:"status" yaml:"status"`
+	ExitCode        int       `json:"exit_code" yaml:"exit_code"`
+	Error           string    `json:"error,omitempty" yaml:"error,omitempty"`
+	StartedAt       time.Time `json:"started_at" yaml:"started_at"`
+	EndedAt         time.Time `json:"ended_at" yaml:"ended_at"`
+	DurationSeconds float64   `json:"duration_seconds" yaml:"duration_seconds"`
+	LogFile         string    `json:"log_file" yaml:"log_file"`
+	LogStartFile    string    `json:"log_start_file" yaml:"log_start_file"`
+	LogStart        int64     `json:"log_start" yaml:"log_start"`
+	LogEndFile      string    `json:"log_end_file" yaml:"log_end_file"`
+	LogEnd          int64     `json:"log_end" yaml:"log_end"`
+}
+
+// appendHistory adds a record to the history of its agent. Each record is a
+// single write, so runs ending at the same time do not interleave.
+func appendHistory(record *runRecord) error {
+	dir, err := agentDir(record.Agent)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	path := filepath.Join(dir, historyFile)
+	if info, err := os.Stat(path); err == nil && info.Size() >= historyMaxSize {
+		if err := rotateHistory(dir); err != nil {
+			return fmt.Errorf("failed to rotate run history: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode run record: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open run history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return f.Close()
+}
+
+// rotateHistory shifts the history files of an agent one generation up,
+// dropping the oldest. A file missing because another run rotated it at the
+// same time is skipped.
+func rotateHistory(dir string) error {
+	files := historyFiles(dir)
+	for i := len(files) - 1; i > 0; i-- {
+		if err := os.Rename(files[i-1], files[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyFiles returns the history files of an agent, newest first
+func historyFiles(dir string) []string {
+	files := []string{filepath.Join(dir, historyFile)}
+	for i := 1; i <= historyBackups; i++ {
+		files = append(files, filepath.Join(dir, fmt.Sprintf("history.%d.jsonl", i)))
+	}
+	return files
+}
+
+// historyFilter selects the runs shown by 'aphelion agent history'
+type historyFilter struct {
+	statuses []string
+	from, to time.Time
+	// limit is the number of runs to return; zero returns them all
+	limit int
+}
+
+func (f historyFilter) matches(record *runRecord) bool {
+	switch {
+	case len(f.statuses) > 0 && !slices.Contains(f.statuses, record.Status):
+		return false
+	case !f.from.IsZero() && record.StartedAt.Before(f.from):
+		return false
+	case !f.to.IsZero() && record.StartedAt.After(f.to):
+		return false
+	}
+	return true
+}
+
+// loadHistory reads the recorded runs of the named agent, or of every agent
+// when name is empty, that match filter, newest first
+func loadHistory(name string, filter historyFilter) ([]*runRecord, error) {
+	names := []string{name}
+	if name == "" {
+		dir, err := agentsDir()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to list agents: %w", err)
+		}
+		names = nil
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	records := []*runRecord{}
+	for _, name := range names {
+		dir, err := agentDir(name)
+		if err != nil {
+			return nil, err
+		}
+		found, err := loadAgentHistory(dir, filter)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, found...)
+	}
+
+	sortHistory(records)
+	if filter.limit > 0 && len(records) > filter.limit {
+		records = records[:filter.limit]
+	}
+	return records, nil
+}
+
+// loadAgentHistory reads the matching runs of one agent, newest first. Runs
+// are appended when they end, so a file last written before a run started
+// holds only runs that started earlier still. Older files are not read once
+// they cannot hold a match, or a run newer than the ones found.
+func loadAgentHistory(dir string, filter historyFilter) ([]*runRecord, error) {
+	var records []*runRecord
+	for _, path := range historyFiles(dir) {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run history: %w", err)
+		}
+		if !filter.from.IsZero() && info.ModTime().Before(filter.from) {
+			break
+		}
+		if filter.limit > 0 && len(records) >= filter.limit {
+			sortHistory(records)
+			records = records[:filter.limit]
+			if records[len(records)-1].StartedAt.After(info.ModTime()) {
+				break
+			}
+		}
+
+		found, err := readHistoryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range found {
+			if filter.matches(record) {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+// sortHistory orders runs newest first
+func sortHistory(records []*runRecord) {
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt.After(records[j].StartedAt) })
+}
+
+// readHistoryFile reads the records of a history file, skipping lines that do
+// not parse, such as one cut short by a full disk
+func readHistoryFile(path string) ([]*runRecord, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	defer f.Close()
+
+	var records []*runRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		record := &runRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err == nil {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	return records, nil
+}
+
+func newHistoryCmd() *cobra.Command {
+	var statuses []string
+	var since, until string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history [name]",
+		Short: "Show the recorded runs of agents",
+		Long: `Show the runs of an agent, or of all agents, newest first. Every run started
+by 'aphelion agent run', once, on a schedule or in the background, is recorded
+in ~/.aphelion/agents/<name>/history.jsonl when it ends, with its trigger,
+schedule, exit code, duration and where its output is in the agent's log.
+
+The history is rotated at 5 MB, keeping history.1.jsonl to history.9.jsonl,
+so the last 50 MB of runs of each agent are kept (around 100,000 runs); older
+runs are dropped.
+
+--since and --until take a time such as 2026-01-31, 2026-01-31T22:00 or an
+RFC 3339 timestamp, or a duration such as 12h or 7d meaning that long ago.
+Use --output json for the full records.`,
+		Args: cobra.MaximumNArgs(1),
+		Example: `  # Did last night's runs succeed?
+  aphelion agent history trial-watcher --since 12h
+
+  # Failed runs of any agent in the last week, for a dashboard
+  aphelion agent history --status failed,timed-out --since 7d --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+				if !agentNamePattern.MatchString(name) {
+					return fmt.Errorf("invalid agent name %q", name)
+				}
+			}
+			for _, status := range statuses {
+				if !slices.Contains(runStatuses, status) {
+					return fmt.Errorf("invalid status %q: must be one of %s", status, strings.Join(runStatuses, ", "))
+				}
+			}
+			if limit < 0 {
+				return fmt.Errorf("--limit must not be negative")
+			}
+			from, err := parseTimeFilter(since)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			to, err := parseTimeFilter(until)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			matched, err := loadHistory(name, historyFilter{statuses: statuses, from: from, to: to, limit: limit})
+			if err != nil {
+				return err
+			}
+
+			if format := config.GetOutputFormat(); format != "table" {
+				return utils.PrintOutput(matched, format)
+			}
+			if len(matched) == 0 {
+				utils.PrintInfo("No runs recorded")
+				return nil
+			}
+
+			printer, err := utils.NewStreamPrinter("table", "Run", "Agent", "Trigger", "Status", "Exit", "Started", "Duration")
+			if err != nil {
+				return err
+			}
+			for _, record := range matched {
+				if err := printer.Print(map[string]interface{}{
+					"Run":      record.ID,
+					"Agent":    record.Agent,
+					"Trigger":  record.Trigger,
+					"Status":   record.Status,
+					"Exit":     record.ExitCode,
+					"Started":  record.StartedAt.Local().Format("2006-01-02 15:04:05"),
+					"Duration": (time.Duration(record.DurationSeconds * float64(time.Second))).Round(time.Millisecond).String(),
+				}); err != nil {
+					return err
+				}
+			}
+			return printer.Close()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "only show runs with these statuses ("+strings.Join(runStatuses, "|")+")")
+	cmd.Flags().StringVar(&since, "since", "", "only show runs started after this time or duration ago")
+	cmd.Flags().StringVar(&until, "until", "", "only show runs started before this time or duration ago")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "maximum number of runs to show (0 for all)")
+
+	return cmd
+}
+
+// parseTimeFilter parses a point in time, given as a date, a local date and
+// time, an RFC 3339 timestamp, or a duration ago. An empty value gives the
+// zero time.
+func parseTimeFilter(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := parseAge(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a time such as 2026-01-31T22:00 nor a duration such as 12h", value)
+}
@@ -0,0 +1,260 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryFilterMatches(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	record := &runRecord{Status: runFailed, StartedAt: started}
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		want   bool
+	}{
+		{name: "no filter", filter: historyFilter{}, want: true},
+		{name: "matching status", filter: historyFilter{statuses: []string{runSucceeded, runFailed}}, want: true},
+		{name: "other status", filter: historyFilter{statuses: []string{runSucceeded}}, want: false},
+		{name: "started after since", filter: historyFilter{from: started.Add(-time.Hour)}, want: true},
+		{name: "started at since", filter: historyFilter{from: started}, want: true},
+		{name: "started before since", filter: historyFilter{from: started.Add(time.Second)}, want: false},
+		{name: "started before until", filter: historyFilter{to: started.Add(time.Hour)}, want: true},
+		{name: "started after until", filter: historyFilter{to: started.Add(-time.Second)}, want: false},
+		{name: "within range", filter: historyFilter{from: started.Add(-time.Hour), to: started.Add(time.Hour), statuses: []string{runFailed}}, want: true},
+		{name: "limit does not filter", filter: historyFilter{limit: 1}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(record); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeFilter(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "2026-01-31", want: time.Date(2026, 1, 31, 0, 0, 0, 0, time.Local)},
+		{value: "2026-01-31T22:00", want: time.Date(2026, 1, 31, 22, 0, 0, 0, time.Local)},
+		{value: "2026-01-31 22:00", want: time.Date(2026, 1, 31, 22, 0, 0, 0, time.Local)},
+		{value: "2026-01-31T22:00:15", want: time.Date(2026, 1, 31, 22, 0, 15, 0, time.Local)},
+		{value: "2026-01-31T22:00:00Z", want: time.Date(2026, 1, 31, 22, 0, 0, 0, time.UTC)},
+		{value: "2026-01-31T22:00:00+02:00", want: time.Date(2026, 1, 31, 20, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+		{value: "2026-13-01", wantErr: true},
+		{value: "-3h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeFilter(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeFilter(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimeFilter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeFilterDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90m": 90 * time.Minute,
+		"12h": 12 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+	}
+
+	for value, ago := range tests {
+		got, err := parseTimeFilter(value)
+		if err != nil {
+			t.Fatalf("parseTimeFilter(%q) failed: %v", value, err)
+		}
+		if d := time.Since(got) - ago; d < 0 || d > time.Minute {
+			t.Errorf("parseTimeFilter(%q) = %v, want %v ago", value, got, ago)
+		}
+	}
+}
+
+// writeHistory writes records to a history file and sets its modification
+// time to when the last of them ended, as appendHistory would have left it
+func writeHistory(t *testing.T, path string, records []*runRecord) {
+	t.Helper()
+
+	var lines []string
+	var last time.Time
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(data))
+		if record.EndedAt.After(last) {
+			last = record.EndedAt
+		}
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, last, last); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hourlyRuns returns n one-minute runs an hour apart, starting at start
+func hourlyRuns(prefix string, start time.Time, n int, status string) []*runRecord {
+	var records []*runRecord
+	for i := 0; i < n; i++ {
+		started := start.Add(time.Duration(i) * time.Hour)
+		records = append(records, &runRecord{
+			ID:        fmt.Sprintf("%s-%d", prefix, i),
+			Agent:     "watcher",
+			Status:    status,
+			StartedAt: started,
+			EndedAt:   started.Add(time.Minute),
+		})
+	}
+	return records
+}
+
+func TestLoadAgentHistory(t *testing.T) {
+	dir := t.TempDir()
+	files := historyFiles(dir)
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeHistory(t, files[2], hourlyRuns("jan", jan, 5, runFailed))
+	writeHistory(t, files[1], hourlyRuns("feb", feb, 5, runSucceeded))
+	writeHistory(t, files[0], hourlyRuns("mar", mar, 5, runSucceeded))
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		want   []string
+	}{
+		{
+			name:   "limit is served from the newest file",
+			filter: historyFilter{limit: 3},
+			want:   []string{"mar-4", "mar-3", "mar-2"},
+		},
+		{
+			name:   "limit spanning files",
+			filter: historyFilter{limit: 7},
+			want:   []string{"mar-4", "mar-3", "mar-2", "mar-1", "mar-0", "feb-4", "feb-3"},
+		},
+		{
+			name:   "status only found in the oldest file",
+			filter: historyFilter{statuses: []string{runFailed}, limit: 2},
+			want:   []string{"jan-4", "jan-3"},
+		},
+		{
+			name:   "since skips older files",
+			filter: historyFilter{from: mar.Add(3 * time.Hour)},
+			want:   []string{"mar-4", "mar-3"},
+		},
+		{
+			name:   "until",
+			filter: historyFilter{to: jan.Add(time.Hour), statuses: []string{runFailed}},
+			want:   []string{"jan-1", "jan-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := loadAgentHistory(dir, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sortHistory(records)
+			if tt.filter.limit > 0 && len(records) > tt.filter.limit {
+				records = records[:tt.filter.limit]
+			}
+
+			var got []string
+			for _, record := range records {
+				got = append(got, record.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("loaded %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAgentHistoryOverlappingRuns(t *testing.T) {
+	dir := t.TempDir()
+	files := historyFiles(dir)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// A long run that started first but ended last lands in the newer file
+	long := &runRecord{ID: "long", Status: runSucceeded, StartedAt: start, EndedAt: start.Add(5 * time.Hour)}
+	older := hourlyRuns("short", start.Add(time.Hour), 3, runSucceeded)
+	writeHistory(t, files[1], older)
+	writeHistory(t, files[0], []*runRecord{long})
+
+	records, err := loadAgentHistory(dir, historyFilter{limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortHistory(records)
+	if len(records) < 2 || records[0].ID != "short-2" || records[1].ID != "short-1" {
+		var got []string
+		for _, record := range records {
+			got = append(got, record.ID)
+		}
+		t.Errorf("loaded %v, want the two latest started runs first", got)
+	}
+}
+
+func TestAppendHistoryRotates(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".aphelion", "agents", "watcher")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := historyFiles(dir)
+
+	// Fill every generation, with the current file at the size limit
+	for i := len(files) - 1; i >= 0; i-- {
+		content := fmt.Sprintf("{\"id\":\"gen-%d\"}\n", i)
+		if i == 0 {
+			content += strings.Repeat(" ", historyMaxSize)
+		}
+		if err := os.WriteFile(files[i], []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := appendHistory(&runRecord{ID: "new", Agent: "watcher"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range map[int]string{0: "new", 1: "gen-0", 2: "gen-1", historyBackups: fmt.Sprintf("gen-%d", historyBackups-1)} {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"id":"`+want+`"`) {
+			t.Errorf("%s does not hold %s", filepath.Base(files[i]), want)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "history*.jsonl"))
+	if len(matches) != historyBackups+1 {
+		t.Errorf("found %d history files, want %d", len(matches), historyBackups+1)
+	}
+}
@@ -1,216 +1,375 @@
 package agent
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
+// agentNamePattern keeps names usable as a Go module and npm package name
+var agentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+type initOptions struct {
+	template string
+	from     string
+	name     string
+	dir      string
+	set      []string
+	force    bool
+}
+
 func newInitCmd() *cobra.Command {
+	var opts initOptions
+	var listTemplates bool
+
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a new agent project",
-		Long:  "Scaffold a new agent project with required files and configuration",
-		RunE:  runInit,
+		Long: fmt.Sprintf(`Scaffold a new agent project with required files and configuration.
+
+The generated agent creates a gateway session, searches for tools, executes
+one and saves the result to memory. Built-in templates: %s.
+
+With --from, the project is created from a template of your own: a local
+directory, a tarball (path or http(s) URL) or a git repository URL, with
+#<branch-or-tag> to pick a ref. Files ending in .tmpl are rendered with Go
+templates and lose the suffix; other files are copied as they are. An
+optional %s at the root of the template describes it:
+
+  name: trial-watcher
+  description: Watches new clinical trials
+  entrypoint: main.py
+  setup:
+    - pip install -r requirements.txt
+  exclude:
+    - docs
+  variables:
+    - name: Condition
+      prompt: Condition to watch
+      default: oncology
+    - name: Schedule
+      choices: [hourly, daily]
+      default: daily
+
+Variables are asked for when running in a terminal and can be given with
+--set name=value. Templates use them as {{.Condition}}, next to the built-in
+{{.Name}}, {{.Description}}, {{.Template}}, {{.Entrypoint}} and {{.APIURL}}.
+
+Existing files are never overwritten unless --force is given.`, strings.Join(templateNames(), ", "), manifestFile),
+		Args: cobra.NoArgs,
+		Example: `  # Create a Python agent in the current directory
+  aphelion agent init
+
+  # Create a Node.js agent in a new directory
+  aphelion agent init --template node --name trial-watcher --dir ./trial-watcher
+
+  # Regenerate the files of an existing project
+  aphelion agent init --template go --force
+
+  # List the built-in templates
+  aphelion agent init --list-templates
+
+  # Create an agent from a template repository
+  aphelion agent init --from https://github.com/acme/agent-starter.git#v1.2 --dir ./watcher
+
+  # Create an agent from a tarball without prompting
+  aphelion agent init --from ./starter.tar.gz --set Condition=cardiology --set Schedule=hourly`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listTemplates {
+				return printTemplates()
+			}
+			return runInit(cmd.Context(), opts)
+		},
 	}
 
+	cmd.Flags().StringVarP(&opts.template, "template", "t", "python", "built-in project template ("+strings.Join(templateNames(), "|")+")")
+	cmd.Flags().StringVar(&opts.from, "from", "", "create the project from a template directory, tarball or git repository")
+	cmd.Flags().StringArrayVar(&opts.set, "set", nil, "set a template variable (name=value, repeatable)")
+	cmd.Flags().BoolVar(&listTemplates, "list-templates", false, "list the built-in templates")
+	cmd.Flags().StringVar(&opts.name, "name", "", "agent name (default is the directory name)")
+	cmd.Flags().StringVar(&opts.dir, "dir", ".", "directory to create the project in")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "overwrite existing files")
+	cmd.MarkFlagsMutuallyExclusive("template", "from")
+
 	return cmd
 }
 
-func runInit(cmd *cobra.Command, args []string) error {
-	// Create .aphelion directory
-	aphelionDir := ".aphelion"
-	if err := os.MkdirAll(aphelionDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .aphelion directory: %w", err)
-	}
-
-	// Create config.yaml
-	configPath := filepath.Join(aphelionDir, "config.yaml")
-	configContent := `# Aphelion Agent Configuration
-name: "my-agent"
-description: "A sample Aphelion agent"
-version: "1.0.0"
-
-# Gateway configuration
-gateway:
-  api_url: "https://api.aphelion.exmplr.ai"
-  
-# Agent execution settings
-execution:
-  memory_checkpoint_interval: "10m"
-  max_memory_entries: 1000
-  
-# Logging configuration
-logging:
-  level: "info"
-  file: "agent.log"
-`
-
-	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to create config.yaml: %w", err)
-	}
-
-	// Create session file
-	sessionPath := filepath.Join(aphelionDir, "session")
-	if err := os.WriteFile(sessionPath, []byte(""), 0644); err != nil {
-		return fmt.Errorf("failed to create session file: %w", err)
-	}
-
-	// Create agent.py
-	agentContent := `#!/usr/bin/env python3
-"""
-Aphelion Agent - Auto-generated template
-"""
-
-import os
-import json
-import time
-import logging
-from typing import Dict, Any, Optional
-from datetime import datetime, timedelta
-
-# Configure logging
-logging.basicConfig(
-    level=logging.INFO,
-    format='%(asctime)s - %(name)s - %(levelname)s - %(message)s'
-)
-logger = logging.getLogger(__name__)
-
-class AphelionAgent:
-    def __init__(self, config_path: str = ".aphelion/config.yaml"):
-        self.config_path = config_path
-        self.session_path = ".aphelion/session"
-        self.session_id = self._load_or_create_session()
-        self.last_memory_checkpoint = datetime.now()
-        
-    def _load_or_create_session(self) -> str:
-        """Load existing session or create a new one"""
-        try:
-            with open(self.session_path, 'r') as f:
-                session_id = f.read().strip()
-                if session_id:
-                    return session_id
-        except FileNotFoundError:
-            pass
-            
-        # Create new session via API
-        # session_id = self._create_session()
-        session_id = f"session_{int(time.time())}"
-        
-        with open(self.session_path, 'w') as f:
-            f.write(session_id)
-            
-        return session_id
-    
-    def _create_session(self) -> str:
-        """Create a new session via Aphelion Gateway API"""
-        # TODO: Implement API call to create session
-        # This would call POST /sessions
-        pass
-    
-    def search_tools(self, query: str) -> Dict[str, Any]:
-        """Search for available tools"""
-        # TODO: Implement API call to search tools
-        # This would call GET /search/tools?q={query}
-        logger.info(f"Searching tools for: {query}")
-        return {"tools": []}
-    
-    def run_tool(self, tool_name: str, params: Dict[str, Any]) -> Dict[str, Any]:
-        """Execute a tool with given parameters"""
-        # TODO: Implement API call to run tool
-        # This would call POST /tools/{tool_name}/execute
-        logger.info(f"Running tool {tool_name} with params: {params}")
-        return {"result": "success"}
-    
-    def save_memory(self, summary: str, content: Dict[str, Any]) -> None:
-        """Save memory to Aphelion Gateway"""
-        # TODO: Implement API call to save memory
-        # This would call POST /memory
-        logger.info(f"Saving memory: {summary}")
-        self.last_memory_checkpoint = datetime.now()
-    
-    def should_checkpoint_memory(self) -> bool:
-        """Check if it's time to checkpoint memory"""
-        # Default: checkpoint every 10 minutes
-        return datetime.now() - self.last_memory_checkpoint > timedelta(minutes=10)
-    
-    def run_cycle(self):
-        """Run one execution cycle of the agent"""
-        try:
-            # Example agent logic - customize this for your use case
-            logger.info("Starting agent cycle...")
-            
-            # 1. Search for relevant tools
-            tools = self.search_tools("Multiple Sclerosis")
-            
-            # 2. Process results and execute tools
-            if tools.get("tools"):
-                # Example: Run a tool
-                result = self.run_tool("exmplr_core.search", {"q": "Multiple Sclerosis"})
-                
-                # 3. Save memory if needed
-                if self.should_checkpoint_memory():
-                    self.save_memory(
-                        "Processed Multiple Sclerosis research",
-                        {"search_results": result, "timestamp": datetime.now().isoformat()}
-                    )
-            
-            logger.info("Agent cycle completed successfully")
-            
-        except Exception as e:
-            logger.error(f"Error in agent cycle: {e}")
-    
-    def run(self):
-        """Main agent execution loop"""
-        logger.info(f"Starting Aphelion Agent with session: {self.session_id}")
-        
-        while True:
-            try:
-                self.run_cycle()
-                # Sleep for 10 minutes before next cycle
-                time.sleep(600)
-                
-            except KeyboardInterrupt:
-                logger.info("Agent stopped by user")
-                break
-            except Exception as e:
-                logger.error(f"Unexpected error: {e}")
-                time.sleep(60)  # Wait 1 minute before retrying
-
-
-if __name__ == "__main__":
-    agent = AphelionAgent()
-    agent.run()
-`
-
-	if err := os.WriteFile("agent.py", []byte(agentContent), 0755); err != nil {
-		return fmt.Errorf("failed to create agent.py: %w", err)
-	}
-
-	// Create requirements.txt
-	requirementsContent := `# Aphelion Agent Dependencies
-requests>=2.28.0
-pyyaml>=6.0
-python-dotenv>=0.19.0
-schedule>=1.1.0
-`
-
-	if err := os.WriteFile("requirements.txt", []byte(requirementsContent), 0644); err != nil {
-		return fmt.Errorf("failed to create requirements.txt: %w", err)
-	}
-
-	fmt.Println("✅ Agent project initialized successfully!")
+func printTemplates() error {
+	printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), "Name", "Description", "Entrypoint")
+	if err != nil {
+		return err
+	}
+	for _, name := range templateNames() {
+		tmpl := agentTemplates[name]
+		printer.Print(map[string]interface{}{
+			"Name":        name,
+			"Description": tmpl.Description,
+			"Entrypoint":  tmpl.Entrypoint,
+		})
+	}
+	return printer.Close()
+}
+
+func runInit(ctx context.Context, opts initOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	absDir, err := filepath.Abs(opts.dir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	name := opts.name
+	if name == "" {
+		name = strings.ToLower(strings.ReplaceAll(filepath.Base(absDir), " ", "-"))
+	}
+	if !agentNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid agent name %q: use lowercase letters, digits, '.', '-' and '_'", name)
+	}
+
+	data := map[string]interface{}{
+		"Name":        name,
+		"Description": "An Aphelion agent",
+		"APIURL":      config.GetAPIUrl(),
+	}
+
+	var files []renderedFile
+	var templateName string
+	var tmpl agentTemplate
+	if opts.from == "" {
+		if len(opts.set) > 0 {
+			return fmt.Errorf("--set is only supported with --from")
+		}
+
+		var ok bool
+		templateName = opts.template
+		if tmpl, ok = agentTemplates[templateName]; !ok {
+			return fmt.Errorf("unknown template %q (available: %s)", templateName, strings.Join(templateNames(), ", "))
+		}
+
+		data["Template"] = templateName
+		data["Entrypoint"] = tmpl.Entrypoint
+		if files, err = renderBuiltin(templateName, data); err != nil {
+			return err
+		}
+	} else {
+		srcDir, cleanup, err := fetchTemplate(ctx, opts.from)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		fsys := os.DirFS(srcDir)
+		manifest, err := readManifest(fsys)
+		if err != nil {
+			return err
+		}
+
+		templateName = manifest.Name
+		if templateName == "" {
+			templateName = opts.from
+		}
+		tmpl = agentTemplate{Description: manifest.Description, Entrypoint: manifest.Entrypoint, Setup: manifest.Setup}
+		if manifest.Description != "" {
+			data["Description"] = manifest.Description
+		}
+		data["Template"] = templateName
+		data["Entrypoint"] = manifest.Entrypoint
+
+		if err := resolveVariables(manifest.Variables, opts.set, data); err != nil {
+			return err
+		}
+		if files, err = renderFS(fsys, data, manifest.excluded); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("template %s has no files", opts.from)
+		}
+	}
+
+	if err := writeProject(absDir, files, opts.force); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Agent project %s initialized from the %s template!\n", name, templateName)
 	fmt.Println("\nCreated files:")
-	fmt.Println("  - agent.py (main agent script)")
-	fmt.Println("  - requirements.txt (Python dependencies)")
-	fmt.Println("  - .aphelion/config.yaml (agent configuration)")
-	fmt.Println("  - .aphelion/session (session management)")
+	for _, file := range files {
+		fmt.Printf("  - %s\n", filepath.Join(opts.dir, file.Path))
+	}
+
 	fmt.Println("\nNext steps:")
-	fmt.Println("  1. Install dependencies: pip install -r requirements.txt")
-	fmt.Println("  2. Customize agent.py for your use case")
-	fmt.Println("  3. Run agent: aphelion agent run ./agent.py")
+	step := 1
+	if opts.dir != "." {
+		fmt.Printf("  %d. cd %s\n", step, opts.dir)
+		step++
+	}
+	for _, setup := range tmpl.Setup {
+		fmt.Printf("  %d. Install dependencies: %s\n", step, setup)
+		step++
+	}
+	if tmpl.Entrypoint != "" {
+		fmt.Printf("  %d. Customize %s for your use case\n", step, tmpl.Entrypoint)
+		fmt.Printf("  %d. Run agent: aphelion agent run ./%s\n", step+1, tmpl.Entrypoint)
+	}
+
+	return nil
+}
+
+// writeProject writes the rendered files below dir. Existing files are only
+// replaced with force, and state files never are.
+func writeProject(dir string, files []renderedFile, force bool) error {
+	if !force {
+		var existing []string
+		for _, file := range files {
+			if stateFiles[file.Path] {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, file.Path)); err == nil {
+				existing = append(existing, file.Path)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to overwrite existing files: %s (use --force to overwrite)", strings.Join(existing, ", "))
+		}
+	}
+
+	for _, file := range files {
+		target := filepath.Join(dir, file.Path)
+		if _, err := os.Stat(target); err == nil && stateFiles[file.Path] {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(target, file.Content, file.Mode); err != nil {
+			return fmt.Errorf("failed to create %s: %w", file.Path, err)
+		}
+		// WriteFile keeps the mode of files that already existed
+		if err := os.Chmod(target, file.Mode); err != nil {
+			return fmt.Errorf("failed to set permissions of %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// resolveVariables adds the manifest variables to data, in the order they are
+// declared: values given with --set first, then answers to prompts when
+// running in a terminal, then defaults
+func resolveVariables(variables []templateVariable, set []string, data map[string]interface{}) error {
+	values := map[string]string{}
+	for _, pair := range set {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid --set %q: expected name=value", pair)
+		}
+		values[name] = value
+	}
+	for name := range values {
+		if !declared(variables, name) {
+			return fmt.Errorf("unknown template variable %q", name)
+		}
+	}
+
+	interactive := term.IsTerminal(int(syscall.Stdin))
+	reader := bufio.NewReader(os.Stdin)
+
+	for _, v := range variables {
+		defaultValue, err := renderString(v.Name+" default", v.Default, data)
+		if err != nil {
+			return err
+		}
 
+		value, ok := values[v.Name]
+		switch {
+		case ok:
+		case interactive:
+			if value, err = promptVariable(reader, v, string(defaultValue)); err != nil {
+				return err
+			}
+		default:
+			value = string(defaultValue)
+		}
+
+		if value == "" && v.Required {
+			return fmt.Errorf("template variable %s is required: pass --set %s=<value>", v.Name, v.Name)
+		}
+		if err := checkChoice(v, value); err != nil {
+			return err
+		}
+		data[v.Name] = value
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// promptVariable asks for a variable until an acceptable value is given. An
+// empty answer takes the default.
+func promptVariable(reader *bufio.Reader, v templateVariable, defaultValue string) (string, error) {
+	prompt := v.Prompt
+	if prompt == "" {
+		prompt = v.Name
+	}
+	if len(v.Choices) > 0 {
+		prompt += " (" + strings.Join(v.Choices, "|") + ")"
+	}
+	if defaultValue != "" {
+		prompt += " [" + defaultValue + "]"
+	}
+	if v.Description != "" {
+		fmt.Println(v.Description)
+	}
+
+	for {
+		fmt.Print(prompt + ": ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", v.Name, err)
+		}
+
+		value := strings.TrimSpace(input)
+		if value == "" {
+			value = defaultValue
+		}
+		if value == "" && v.Required {
+			utils.PrintWarning("%s is required", v.Name)
+			continue
+		}
+		if err := checkChoice(v, value); err != nil {
+			utils.PrintWarning("%v", err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+func checkChoice(v templateVariable, value string) error {
+	if len(v.Choices) == 0 || (value == "" && !v.Required) {
+		return nil
+	}
+	for _, choice := range v.Choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q for %s: must be one of %s", value, v.Name, strings.Join(v.Choices, ", "))
+}
+
+func declared(variables []templateVariable, name string) bool {
+	for _, v := range variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
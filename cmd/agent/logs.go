@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// followInterval is how often 'agent logs -f' checks the log for new output
+const followInterval = 250 * time.Millisecond
+
+func newLogsCmd() *cobra.Command {
+	var follow bool
+	var tail int
+	var supervisor bool
+
+	cmd := &cobra.Command{
+		Use:   "logs <name>",
+		Short: "Show the log of an agent",
+		Long: `Show the captured output of an agent's runs. Every line is prefixed with
+its time, the run ID and the stream it came from (stdout, stderr, or event
+for starts and exits).
+
+Agents started with 'aphelion agent run --daemon' are found by name wherever
+their project puts the log; other agents by the default log in
+~/.aphelion/agents/<name>/. With --supervisor, the output of the background
+supervisor is shown instead. With --follow, new output is printed as it is
+written until interrupted.`,
+		Args: cobra.ExactArgs(1),
+		Example: `  # Show the whole log
+  aphelion agent logs trial-watcher
+
+  # Show the last 50 lines and follow new output
+  aphelion agent logs trial-watcher -n 50 -f
+
+  # Show what the background supervisor reported
+  aphelion agent logs trial-watcher --supervisor`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := logPath(args[0], supervisor)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			return showLog(ctx, path, tail, follow)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "follow new output")
+	cmd.Flags().IntVarP(&tail, "tail", "n", -1, "number of lines to show from the end of the log (-1 for all)")
+	cmd.Flags().BoolVar(&supervisor, "supervisor", false, "show the output of the background supervisor")
+
+	return cmd
+}
+
+// logPath finds the log of the named agent. Background agents record where
+// their log is; other agents are looked for in the default location.
+func logPath(name string, supervisor bool) (string, error) {
+	state, err := loadState(name)
+	if err == nil {
+		if supervisor {
+			return state.SupervisorLog, nil
+		}
+		return state.LogFile, nil
+	}
+	if supervisor {
+		return "", err
+	}
+
+	dir, dirErr := agentDir(name)
+	if dirErr != nil {
+		return "", dirErr
+	}
+	path := filepath.Join(dir, logFile)
+	if _, statErr := os.Stat(path); statErr != nil {
+		return "", fmt.Errorf("no log for agent %q in %s", name, dir)
+	}
+	return path, nil
+}
+
+// showLog prints the last lines of a log, or all of it when lines is
+// negative, and with follow keeps printing what is appended to it. A log
+// that is rotated or truncated while following is read again from the start.
+func showLog(ctx context.Context, path string, lines int, follow bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer func() { f.Close() }()
+
+	if lines >= 0 {
+		offset, err := tailOffset(f, lines)
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+	}
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if _, err := io.Copy(os.Stdout, f); err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+
+		current, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		latest, err := os.Stat(path)
+		if err != nil {
+			// Between rotating the log and creating the new one
+			continue
+		}
+
+		offset, _ := f.Seek(0, io.SeekCurrent)
+		switch {
+		case !os.SameFile(current, latest):
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f = next
+		case latest.Size() < offset:
+			f.Seek(0, io.SeekStart)
+		}
+	}
+}
+
+// tailOffset returns the offset of the last n lines of f
+func tailOffset(f *os.File, n int) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := info.Size()
+	if n == 0 {
+		return end, nil
+	}
+
+	const blockSize = 32 * 1024
+	buf := make([]byte, blockSize)
+	newlines := 0
+
+	for pos := end; pos > 0; {
+		size := int64(blockSize)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		if _, err := f.ReadAt(buf[:size], pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		for i := size - 1; i >= 0; i-- {
+			// A newline ending the log does not start another line
+			if buf[i] != '\n' || pos+i == end-1 {
+				continue
+			}
+			if newlines++; newlines == n {
+				return pos + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
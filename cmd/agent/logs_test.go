@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTailOffset(t *testing.T) {
+	// Lines longer than the read block make the scan cross block boundaries
+	long := strings.Repeat("x", 40*1024)
+
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    string
+	}{
+		{name: "empty log", content: "", n: 10, want: ""},
+		{name: "no lines wanted", content: "a\nb\n", n: 0, want: ""},
+		{name: "last line", content: "a\nb\nc\n", n: 1, want: "c\n"},
+		{name: "last two lines", content: "a\nb\nc\n", n: 2, want: "b\nc\n"},
+		{name: "more lines than the log has", content: "a\nb\n", n: 5, want: "a\nb\n"},
+		{name: "exactly all lines", content: "a\nb\n", n: 2, want: "a\nb\n"},
+		{name: "unterminated last line", content: "a\nb\nc", n: 1, want: "c"},
+		{name: "unterminated last two lines", content: "a\nb\nc", n: 2, want: "b\nc"},
+		{name: "empty lines count", content: "a\n\n\n", n: 2, want: "\n\n"},
+		{name: "lines across blocks", content: long + "\n" + long + "\n" + "tail\n", n: 2, want: long + "\n" + "tail\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agent.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			offset, err := tailOffset(f, tt.n)
+			if err != nil {
+				t.Fatalf("tailOffset failed: %v", err)
+			}
+			if got := tt.content[offset:]; got != tt.want {
+				t.Errorf("tailOffset(%d) starts at %q, want %q", tt.n, abbreviate(got), abbreviate(tt.want))
+			}
+		})
+	}
+}
+
+func abbreviate(s string) string {
+	if len(s) > 40 {
+		return s[:20] + "..." + s[len(s)-20:]
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a process group of its own, so
+// terminal signals meant for the CLI do not reach it directly and the whole
+// group can be signalled
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// detachProcess starts the command in a new session, without a controlling
+// terminal, so it keeps running after the CLI exits
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// terminateProcess sends SIGTERM to the process group led by pid
+func terminateProcess(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+// killProcess sends SIGKILL to the process group led by pid
+func killProcess(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// processAlive reports whether a process with the given pid is running.
+// Zombies, which are left over where nothing reaps orphaned processes, are
+// recognized on systems with /proc.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// The state follows the command name, which is in parentheses
+	if i := strings.LastIndexByte(string(stat), ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
+
+// processStart identifies when the process with the given pid started, so
+// that a pid reused by an unrelated process can be told apart. On Linux this
+// is the start in clock ticks since boot, qualified by the boot ID; elsewhere
+// the start time reported by ps.
+func processStart(pid int) (string, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err == nil {
+		// starttime is the 22nd field, the 20th after the command name
+		i := strings.LastIndexByte(string(stat), ')')
+		if i < 0 {
+			return "", fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+		}
+		fields := strings.Fields(string(stat[i+1:]))
+		if len(fields) < 20 {
+			return "", fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+		}
+		bootID, _ := os.ReadFile("/proc/sys/kernel/random/boot_id")
+		return strings.TrimSpace(string(bootID)) + "/" + fields[19], nil
+	}
+
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get start time of process %d: %w", pid, err)
+	}
+	start := strings.TrimSpace(string(out))
+	if start == "" {
+		return "", fmt.Errorf("failed to get start time of process %d", pid)
+	}
+	return start, nil
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package agent
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	detachedProcess         = 0x00000008
+	stillActive             = 259
+	processQueryLimitedInfo = 0x1000
+)
+
+// setProcessGroup starts the command in a process group of its own, so
+// console interrupts meant for the CLI do not reach it directly
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// detachProcess starts the command without a console, so it keeps running
+// after the CLI exits
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// terminateProcess is not supported on Windows, which has no SIGTERM; the
+// caller kills the process instead
+func terminateProcess(pid int) error {
+	return errors.New("graceful termination is not supported on Windows")
+}
+
+// killProcess terminates the process
+func killProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// processAlive reports whether a process with the given pid is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(processQueryLimitedInfo, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == stillActive
+}
+
+// processStart identifies when the process with the given pid started, so
+// that a pid reused by an unrelated process can be told apart
+func processStart(pid int) (string, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInfo, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(h)
+
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10), nil
+}
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfig is the part of an agent project's .aphelion/config.yaml the
+// CLI uses
+type projectConfig struct {
+	Name    string        `yaml:"name"`
+	Logging loggingConfig `yaml:"logging"`
+}
+
+// loggingConfig controls where agent output is captured and how the log is
+// rotated
+type loggingConfig struct {
+	// File is the log file, relative to the project directory
+	File string `yaml:"file"`
+	// MaxSize is the size at which the log is rotated, e.g. "10MB"
+	MaxSize string `yaml:"max_size"`
+	// MaxAge is how long rotated logs are kept, e.g. "7d" or "72h"
+	MaxAge string `yaml:"max_age"`
+	// MaxBackups is how many rotated logs are kept
+	MaxBackups *int `yaml:"max_backups"`
+	// Compress gzips rotated logs
+	Compress *bool `yaml:"compress"`
+}
+
+// Log rotation defaults, for projects that do not set them
+const (
+	defaultLogMaxSize    = 10 << 20
+	defaultLogMaxAge     = 7 * 24 * time.Hour
+	defaultLogMaxBackups = 5
+)
+
+// loadProjectConfig reads the .aphelion/config.yaml next to an agent file. A
+// missing file gives an empty config.
+func loadProjectConfig(agentFile string) (*projectConfig, error) {
+	project := &projectConfig{}
+
+	path := filepath.Join(filepath.Dir(agentFile), ".aphelion", "config.yaml")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return project, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return project, nil
+}
+
+// logOptions resolves the logging settings of a project. The log defaults to
+// ~/.aphelion/agents/<name>/agent.log.
+func (p *projectConfig) logOptions(agentFile, name string) (rotateOptions, error) {
+	opts := rotateOptions{
+		MaxSize:    defaultLogMaxSize,
+		MaxAge:     defaultLogMaxAge,
+		MaxBackups: defaultLogMaxBackups,
+		Compress:   true,
+	}
+	logging := p.Logging
+
+	switch {
+	case logging.File == "":
+		dir, err := agentDir(name)
+		if err != nil {
+			return opts, err
+		}
+		opts.Path = filepath.Join(dir, logFile)
+	case strings.HasPrefix(logging.File, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return opts, fmt.Errorf("could not get home directory: %w", err)
+		}
+		opts.Path = filepath.Join(home, logging.File[2:])
+	case filepath.IsAbs(logging.File):
+		opts.Path = logging.File
+	default:
+		opts.Path = filepath.Join(filepath.Dir(agentFile), logging.File)
+	}
+
+	if logging.MaxSize != "" {
+		size, err := parseSize(logging.MaxSize)
+		if err != nil {
+			return opts, fmt.Errorf("invalid logging.max_size %q: %w", logging.MaxSize, err)
+		}
+		opts.MaxSize = size
+	}
+	if logging.MaxAge != "" {
+		age, err := parseAge(logging.MaxAge)
+		if err != nil {
+			return opts, fmt.Errorf("invalid logging.max_age %q: %w", logging.MaxAge, err)
+		}
+		opts.MaxAge = age
+	}
+	if logging.MaxBackups != nil {
+		if *logging.MaxBackups < 0 {
+			return opts, fmt.Errorf("invalid logging.max_backups %d: must not be negative", *logging.MaxBackups)
+		}
+		opts.MaxBackups = *logging.MaxBackups
+	}
+	if logging.Compress != nil {
+		opts.Compress = *logging.Compress
+	}
+	return opts, nil
+}
+
+// parseSize parses a byte size such as 512KB, 10MB or 1GB; plain numbers are
+// bytes
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("must be a positive size such as 10MB")
+	}
+	return n * multiplier, nil
+}
+
+// parseAge parses a duration, also accepting days such as 7d. Zero keeps
+// rotated logs regardless of their age.
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("must be a duration such as 7d or 72h")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("must be a duration such as 7d or 72h")
+	}
+	return d, nil
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "1024", want: 1024},
+		{value: "100B", want: 100},
+		{value: "512KB", want: 512 << 10},
+		{value: "10MB", want: 10 << 20},
+		{value: "1GB", want: 1 << 30},
+		{value: "10mb", want: 10 << 20},
+		{value: " 10 MB ", want: 10 << 20},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-5MB", wantErr: true},
+		{value: "1.5MB", wantErr: true},
+		{value: "10TB", wantErr: true},
+		{value: "MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "0d", want: 0},
+		{value: "72h", want: 72 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "0", want: 0},
+		{value: "-1d", wantErr: true},
+		{value: "-2h", wantErr: true},
+		{value: "1.5d", wantErr: true},
+		{value: "week", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAge(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newPsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ps",
+		Short: "List background agents",
+		Long: `List the agents started with 'aphelion agent run --daemon', whether they are
+still running, and how often they were restarted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			states, err := loadStates()
+			if err != nil {
+				return err
+			}
+
+			printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), "Name", "Status", "PID", "Restarts", "Started", "Uptime", "Agent")
+			if err != nil {
+				return err
+			}
+			printer.Empty("No background agents. Start one with 'aphelion agent run <file> --daemon'")
+
+			for _, state := range states {
+				// A pid left behind by a crash or reboot may belong to
+				// another process by now
+				if _, err := state.clearStale(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				status := state.status()
+				pid, uptime := "", ""
+				if status == statusRunning {
+					pid = fmt.Sprint(state.PID)
+					uptime = time.Since(state.StartedAt).Round(time.Second).String()
+				}
+
+				if err := printer.Print(map[string]interface{}{
+					"Name":     state.Name,
+					"Status":   status,
+					"PID":      pid,
+					"Restarts": state.Restarts,
+					"Started":  state.StartedAt.Local().Format("2006-01-02 15:04"),
+					"Uptime":   uptime,
+					"Agent":    state.AgentFile,
+				}); err != nil {
+					return err
+				}
+			}
+
+			return printer.Close()
+		},
+	}
+
+	return cmd
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,26 +13,110 @@ import (
 
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
+// agentTokenValidity is how long the token handed to an agent run must stay
+// valid; tokens closer to expiry are renewed first
+const agentTokenValidity = 10 * time.Minute
+
 var (
 	cronSchedule string
 	daemon       bool
 	verbose      bool
+
+	restart         string
+	maxRestarts     int
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+	gracePeriod     time.Duration
+	agentName       string
+	supervise       bool
+
+	overlap    string
+	runTimeout time.Duration
+	jitter     time.Duration
+	timezone   string
+	preview    int
 )
 
+// daemonFlags only apply to agents run with --daemon
+var daemonFlags = []string{"restart", "max-restarts", "restart-delay", "max-restart-delay"}
+
+// cronFlags only apply to agents run with --cron
+var cronFlags = []string{"overlap", "run-timeout", "jitter", "timezone", "next"}
+
 func newRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run [agent-file]",
 		Short: "Run an agent",
-		Long:  "Execute an agent script with optional cron scheduling",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runAgent,
+		Long: `Execute an agent script with optional cron scheduling.
+
+The output of every run is captured to the agent's log, each line prefixed
+with the time, the run ID and the stream (stdout, stderr, or event for starts
+and exits). The log is the logging.file of the project's .aphelion/config.yaml,
+by default ~/.aphelion/agents/<name>/agent.log, and is rotated once it reaches
+logging.max_size (10MB), keeping logging.max_backups (5) gzipped old logs for
+up to logging.max_age (7d). The agent gets its run ID in APHELION_RUN_ID.
+
+With --daemon the agent runs in the background under the name given with
+--name, which defaults to the name in the project's .aphelion/config.yaml.
+Its state is kept in ~/.aphelion/agents/<name>/; manage it with
+'aphelion agent ps', 'stop', 'restart' and 'logs'.
+
+The background agent is supervised: it is restarted according to --restart,
+waiting --restart-delay before the first restart and twice as long after each
+failure that comes within 10s of starting, up to --max-restart-delay. Five
+such failures in a row are treated as a crash loop and stop the supervisor.
+On SIGINT or SIGTERM the agent receives SIGTERM and is killed if it has not
+exited after --grace-period.
+
+--cron takes a standard five field schedule, six fields starting with seconds,
+or a descriptor such as @hourly or @every 30s, in the time zone given with
+--timezone, a CRON_TZ= prefix or the CRON_TZ environment variable. With
+--next the schedule is checked and its next run times are shown without
+running the agent. A run that comes due while the previous one is still going
+is skipped by default; --overlap queue starts it once the previous run ends
+(at most one run waits), --overlap allow starts it right away. --jitter
+delays each run by a random time up to the given duration, and runs that take
+longer than --run-timeout receive SIGTERM and are killed after --grace-period.`,
+		Example: `  # Run an agent once
+  aphelion agent run ./agent.py
+
+  # Keep an agent running in the background, restarting it whenever it fails
+  aphelion agent run ./agent.py --daemon
+  aphelion agent logs my-agent -f
+
+  # Restart at most 5 times, whatever the exit status
+  aphelion agent run ./agent.py --daemon --restart always --max-restarts 5
+
+  # Run every weekday at 9:00 in New York, stopping runs after 10 minutes
+  aphelion agent run ./agent.py --cron "0 9 * * 1-5" --timezone America/New_York --run-timeout 10m
+
+  # Show when a schedule with a seconds field runs next
+  aphelion agent run ./agent.py --cron "*/30 * * * * *" --next 5`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAgent,
 	}
 
 	cmd.Flags().StringVar(&cronSchedule, "cron", "", "Cron schedule for agent execution (e.g., '*/10 * * * *')")
+	cmd.Flags().StringVar(&overlap, "overlap", string(overlapSkip), "what to do when a scheduled run comes due while the previous one is going (allow|skip|queue)")
+	cmd.Flags().DurationVar(&runTimeout, "run-timeout", 0, "stop scheduled runs that take longer than this (0 for no limit)")
+	cmd.Flags().DurationVar(&jitter, "jitter", 0, "delay each scheduled run by a random time up to this")
+	cmd.Flags().StringVar(&timezone, "timezone", "", "time zone of the cron schedule, e.g. Europe/Berlin (default is $CRON_TZ or local time)")
+	cmd.Flags().IntVar(&preview, "next", 0, "show the next N run times of the cron schedule and exit")
 	cmd.Flags().BoolVarP(&daemon, "daemon", "d", false, "Run agent as daemon")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	cmd.Flags().StringVar(&restart, "restart", string(restartOnFailure), "when to restart a daemon agent (never|on-failure|always)")
+	cmd.Flags().IntVar(&maxRestarts, "max-restarts", 0, "maximum number of restarts of a daemon agent (0 for no limit)")
+	cmd.Flags().DurationVar(&restartDelay, "restart-delay", time.Second, "wait before restarting a daemon agent, doubled after each quick failure")
+	cmd.Flags().DurationVar(&maxRestartDelay, "max-restart-delay", 5*time.Minute, "maximum wait before restarting a daemon agent")
+	cmd.Flags().DurationVar(&gracePeriod, "grace-period", 10*time.Second, "time an agent has to exit after SIGTERM before it is killed (with --daemon or --cron)")
+	cmd.Flags().StringVar(&agentName, "name", "", "agent name, used for its log and background state (default is the project name)")
+	cmd.Flags().BoolVar(&supervise, superviseFlag, false, "supervise the agent in this process")
+	cmd.Flags().MarkHidden(superviseFlag)
 
 	return cmd
 }
@@ -50,62 +135,129 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	if cronSchedule != "" {
-		return runWithCron(absPath)
+	if !daemon {
+		for _, name := range daemonFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s requires --daemon", name)
+			}
+		}
+		if cronSchedule == "" && cmd.Flags().Changed("grace-period") {
+			return fmt.Errorf("--grace-period requires --daemon or --cron")
+		}
+	}
+	if cronSchedule == "" {
+		for _, name := range cronFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s requires --cron", name)
+			}
+		}
+	} else {
+		schedule, loc, err := validateCronFlags()
+		if err != nil {
+			return err
+		}
+		if preview > 0 {
+			fmt.Printf("📅 Next runs of %s (%s):\n", cronSchedule, loc)
+			for _, t := range nextRuns(schedule, loc, preview) {
+				fmt.Printf("   %s\n", t.Format("Mon 2006-01-02 15:04:05 MST"))
+			}
+			return nil
+		}
+	}
+
+	project, err := loadProjectConfig(absPath)
+	if err != nil {
+		return err
+	}
+	name, err := resolveAgentName(absPath, project, agentName)
+	if err != nil {
+		return err
 	}
 
 	if daemon {
-		return runAsDaemon(absPath)
+		return runAsDaemon(cmd, absPath, name, project)
 	}
 
-	return runOnce(absPath)
+	log, err := openAgentLog(absPath, name, project)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	if cronSchedule != "" {
+		return runWithCron(absPath, log)
+	}
+
+	return runOnce(absPath, log)
 }
 
-func runOnce(agentFile string) error {
+func runOnce(agentFile string, log *agentLog) error {
+	run := log.newRun(agentFile, os.Stdout, os.Stderr)
 	if verbose {
-		fmt.Printf("🚀 Running agent: %s\n", agentFile)
+		fmt.Printf("🚀 Running agent: %s (run %s, logged to %s)\n", agentFile, run.ID, log.Path())
 	}
 
-	cmd := createAgentCommand(agentFile)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Ctrl+C reaches the agent, which shares the terminal; keep running
+	// until it has exited so the end of its output is logged, and record
+	// the run as stopped
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+	defer signal.Stop(sigChan)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigChan:
+			run.endAs(runStopped)
+		case <-done:
+		}
+	}()
 
-	return cmd.Run()
+	return run.run()
 }
 
-func runWithCron(agentFile string) error {
-	fmt.Printf("📅 Scheduling agent with cron: %s\n", cronSchedule)
-	fmt.Printf("🚀 Agent file: %s\n", agentFile)
-
-	c := cron.New()
-	
-	_, err := c.AddFunc(cronSchedule, func() {
-		if verbose {
-			fmt.Printf("[%s] 🔄 Running scheduled agent execution\n", time.Now().Format("2006-01-02 15:04:05"))
-		}
-		
-		cmd := createAgentCommand(agentFile)
-		
-		if verbose {
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-		}
-		
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("❌ Agent execution failed: %v\n", err)
-		} else if verbose {
-			fmt.Printf("✅ Agent execution completed successfully\n")
-		}
-	})
+// validateCronFlags checks the cron schedule and the flags that go with it
+func validateCronFlags() (cron.Schedule, *time.Location, error) {
+	if _, err := parseOverlapPolicy(overlap); err != nil {
+		return nil, nil, err
+	}
+	if runTimeout < 0 {
+		return nil, nil, fmt.Errorf("--run-timeout must not be negative")
+	}
+	if jitter < 0 {
+		return nil, nil, fmt.Errorf("--jitter must not be negative")
+	}
+	if preview < 0 {
+		return nil, nil, fmt.Errorf("--next must not be negative")
+	}
+	return parseSchedule(cronSchedule, timezone)
+}
 
+func runWithCron(agentFile string, log *agentLog) error {
+	schedule, loc, err := validateCronFlags()
 	if err != nil {
-		return fmt.Errorf("invalid cron schedule: %w", err)
+		return err
 	}
+	policy, _ := parseOverlapPolicy(overlap)
+
+	fmt.Printf("📅 Scheduling agent with cron: %s (%s, overlap: %s)\n", cronSchedule, loc, policy)
+	fmt.Printf("🚀 Agent file: %s\n", agentFile)
+	fmt.Printf("📝 Agent output is logged to %s\n", log.Path())
 
+	job := newCronJob(agentFile, cronSchedule, log)
+	job.overlap = policy
+	job.jitter = jitter
+	job.runTimeout = runTimeout
+	job.gracePeriod = gracePeriod
+
+	c := cron.New(cron.WithLocation(loc))
+	c.Schedule(schedule, job)
 	c.Start()
-	defer c.Stop()
 
 	fmt.Println("⏰ Cron scheduler started. Press Ctrl+C to stop.")
+	if next := nextRuns(schedule, loc, 1); len(next) > 0 {
+		fmt.Printf("   Next run: %s\n", next[0].Format("Mon 2006-01-02 15:04:05 MST"))
+	}
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
@@ -113,56 +265,123 @@ func runWithCron(agentFile string) error {
 	<-sigChan
 
 	fmt.Println("\n🛑 Stopping cron scheduler...")
+	// Stop only keeps new runs from being scheduled. job.stop ends the active
+	// runs and waits for them, after which the context of Stop is done too.
+	ctx := c.Stop()
+	job.stop()
+	<-ctx.Done()
 	return nil
 }
 
-func runAsDaemon(agentFile string) error {
-	fmt.Printf("🔄 Running agent as daemon: %s\n", agentFile)
-
-	// Setup signal handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// runAsDaemon starts the agent in the background or, in the background
+// process, supervises it. Agents with --cron run their schedule in the
+// background instead.
+func runAsDaemon(cmd *cobra.Command, agentFile, name string, project *projectConfig) error {
+	policy, err := parseRestartPolicy(restart)
+	if err != nil {
+		return err
+	}
+	if maxRestarts < 0 {
+		return fmt.Errorf("--max-restarts must not be negative")
+	}
+	if restartDelay <= 0 || maxRestartDelay < restartDelay {
+		return fmt.Errorf("--restart-delay must be positive and not exceed --max-restart-delay")
+	}
 
-	// Run agent in a goroutine
-	done := make(chan error, 1)
-	go func() {
-		cmd := createAgentCommand(agentFile)
-		
-		if verbose {
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
+	if !supervise {
+		// Report logging settings the background process would fail on
+		if _, err := project.logOptions(agentFile, name); err != nil {
+			return err
 		}
-		
-		done <- cmd.Run()
-	}()
 
-	// Wait for either completion or signal
-	select {
-	case err := <-done:
+		command := os.Args[1:]
+		if !cmd.Flags().Changed("name") {
+			command = append(command, "--name", name)
+		}
+		cwd, err := os.Getwd()
 		if err != nil {
-			return fmt.Errorf("agent execution failed: %w", err)
+			return fmt.Errorf("failed to get working directory: %w", err)
 		}
-		return nil
-	case sig := <-sigChan:
-		fmt.Printf("\n🛑 Received signal %v, stopping agent...\n", sig)
-		return nil
+		return startDetached(name, command, cwd)
 	}
+
+	// Errors end up in the supervisor log, where usage help would only be
+	// noise
+	cmd.SilenceUsage = true
+
+	log, err := openAgentLog(agentFile, name, project)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	err = runSupervised(name, agentFile, log.Path(), gracePeriod, func(onStart func(pid, restarts int)) error {
+		if cronSchedule != "" {
+			return runWithCron(agentFile, log)
+		}
+
+		fmt.Printf("🔄 Running agent as daemon: %s (restart: %s)\n", agentFile, policy)
+		s := &supervisor{
+			agentFile:       agentFile,
+			policy:          policy,
+			maxRestarts:     maxRestarts,
+			restartDelay:    restartDelay,
+			maxRestartDelay: maxRestartDelay,
+			gracePeriod:     gracePeriod,
+			log:             log,
+			onStart:         onStart,
+		}
+		return s.run()
+	})
+	if err != nil {
+		log.event(noRunID, "supervisor stopped: %v", err)
+	}
+	return err
 }
 
-func createAgentCommand(agentFile string) *exec.Cmd {
+func createAgentCommand(agentFile, runID string) *exec.Cmd {
+	var cmd *exec.Cmd
+
 	// Determine how to run the agent based on file extension
 	ext := strings.ToLower(filepath.Ext(agentFile))
 	
 	switch ext {
 	case ".py":
-		return exec.Command("python3", agentFile)
+		cmd = exec.Command("python3", agentFile)
 	case ".js":
-		return exec.Command("node", agentFile)
+		cmd = exec.Command("node", agentFile)
 	case ".go":
-		return exec.Command("go", "run", agentFile)
+		cmd = exec.Command("go", "run", agentFile)
 	default:
 		// Try to make it executable and run directly
 		os.Chmod(agentFile, 0755)
-		return exec.Command(agentFile)
+		cmd = exec.Command(agentFile)
+	}
+
+	// Agents find their .aphelion directory relative to the project
+	cmd.Dir = filepath.Dir(agentFile)
+	cmd.Env = append(agentEnv(), "APHELION_RUN_ID="+runID)
+	return cmd
+}
+
+// agentEnv passes the gateway URL and a current access token to the agent, so
+// it can call the gateway as the logged in account. It is called for every
+// run, so cron runs and supervisor restarts each get a renewed token; a run
+// that lasts longer than the token keeps the expired one.
+func agentEnv() []string {
+	env := append(os.Environ(), "APHELION_API_URL="+config.GetAPIUrl())
+	// Python buffers output to pipes, which would hold back the log
+	if _, ok := os.LookupEnv("PYTHONUNBUFFERED"); !ok {
+		env = append(env, "PYTHONUNBUFFERED=1")
+	}
+
+	token, err := api.AccessToken(context.Background(), agentTokenValidity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; the agent gets the current access token\n", err)
+		token = config.GetAccessToken()
+	}
+	if token != "" {
+		env = append(env, "APHELION_TOKEN="+token)
 	}
+	return env
 }
\ No newline at end of file
@@ -0,0 +1,313 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// overlapPolicy decides what happens when a scheduled run comes due while
+// the previous one is still going
+type overlapPolicy string
+
+const (
+	overlapAllow overlapPolicy = "allow"
+	overlapSkip  overlapPolicy = "skip"
+	overlapQueue overlapPolicy = "queue"
+)
+
+func parseOverlapPolicy(value string) (overlapPolicy, error) {
+	switch policy := overlapPolicy(value); policy {
+	case overlapAllow, overlapSkip, overlapQueue:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid overlap policy %q: must be one of allow, skip or queue", value)
+}
+
+// cronParser accepts standard five field schedules, six fields starting with
+// seconds, descriptors such as @hourly and @every, and a CRON_TZ= prefix
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// parseSchedule parses a cron schedule and the time zone it runs in: the
+// CRON_TZ= prefix of the schedule, then timezone, then the CRON_TZ
+// environment variable, then local time
+func parseSchedule(spec, timezone string) (cron.Schedule, *time.Location, error) {
+	spec = strings.TrimSpace(spec)
+
+	if timezone == "" {
+		timezone = os.Getenv("CRON_TZ")
+	}
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %w", timezone, err)
+		}
+	}
+
+	// The parser quietly rounds @every durations to whole seconds of at
+	// least 1s
+	if value, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+		}
+		if d < time.Second || d%time.Second != 0 {
+			return nil, nil, fmt.Errorf("invalid cron schedule %q: @every needs a whole number of seconds, at least 1s", spec)
+		}
+	}
+
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+	}
+	if s, ok := schedule.(*cron.SpecSchedule); ok {
+		if s.Location == time.Local {
+			s.Location = loc
+		} else {
+			loc = s.Location
+		}
+	}
+
+	if schedule.Next(time.Now().In(loc)).IsZero() {
+		return nil, nil, fmt.Errorf("cron schedule %q never runs", spec)
+	}
+	return schedule, loc, nil
+}
+
+// nextRuns returns the next n times a schedule runs
+func nextRuns(schedule cron.Schedule, loc *time.Location, n int) []time.Time {
+	var times []time.Time
+	t := time.Now().In(loc)
+	for i := 0; i < n; i++ {
+		if t = schedule.Next(t); t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// cronJob runs an agent on every tick of its schedule, applying the overlap
+// policy, jitter and run timeout
+type cronJob struct {
+	agentFile string
+	schedule  string
+	log       *agentLog
+	overlap   overlapPolicy
+	// jitter is the upper bound of a random delay before each run
+	jitter time.Duration
+	// runTimeout stops runs that take longer; zero means no limit
+	runTimeout time.Duration
+	// gracePeriod is how long a run has to exit after SIGTERM before it is
+	// killed
+	gracePeriod time.Duration
+
+	mu sync.Mutex
+	// running counts the runs in progress, including those waiting out
+	// their jitter
+	running int
+	// queued is set when a run came due while another was going
+	queued   bool
+	active   map[*agentRun]chan struct{}
+	stopping bool
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newCronJob(agentFile, schedule string, log *agentLog) *cronJob {
+	return &cronJob{
+		agentFile: agentFile,
+		schedule:  schedule,
+		log:       log,
+		active:    make(map[*agentRun]chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Run is called by the scheduler on every tick
+func (j *cronJob) Run() {
+	if !j.claim() {
+		return
+	}
+	for {
+		j.execute()
+		if !j.next() {
+			return
+		}
+	}
+}
+
+// claim reports whether a run may start now, queueing or skipping it
+// otherwise
+func (j *cronJob) claim() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopping {
+		return false
+	}
+	if j.running > 0 {
+		switch {
+		case j.overlap == overlapSkip:
+			j.report("⏭️", "Skipped scheduled run: the previous run is still going")
+			return false
+		case j.overlap == overlapQueue && j.queued:
+			j.report("⏭️", "Skipped scheduled run: a run is already queued")
+			return false
+		case j.overlap == overlapQueue:
+			j.queued = true
+			j.report("⏳", "Queued scheduled run until the previous run ends")
+			return false
+		}
+	}
+	j.running++
+	j.wg.Add(1)
+	return true
+}
+
+// next reports whether a queued run should start now that one has ended
+func (j *cronJob) next() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.queued && !j.stopping {
+		j.queued = false
+		return true
+	}
+	j.running--
+	j.wg.Done()
+	return false
+}
+
+func (j *cronJob) execute() {
+	if delay := j.jitterDelay(); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-j.stopped:
+			return
+		}
+	}
+
+	var stdout, stderr io.Writer
+	if verbose {
+		stdout, stderr = os.Stdout, os.Stderr
+	}
+	run := j.log.newRun(j.agentFile, stdout, stderr)
+	run.trigger = triggerCron
+	run.schedule = j.schedule
+	setProcessGroup(run.cmd)
+
+	if verbose {
+		fmt.Printf("[%s] 🔄 Running scheduled agent execution (run %s)\n", time.Now().Format("2006-01-02 15:04:05"), run.ID)
+	}
+
+	// Registering the run and starting it under the lock keeps stop from
+	// missing it
+	j.mu.Lock()
+	if j.stopping {
+		j.mu.Unlock()
+		return
+	}
+	err := run.start()
+	done := make(chan struct{})
+	if err == nil {
+		j.active[run] = done
+	}
+	j.mu.Unlock()
+	if err != nil {
+		fmt.Printf("❌ Agent execution failed (run %s): %v\n", run.ID, err)
+		return
+	}
+
+	var timedOut atomic.Bool
+	if j.runTimeout > 0 {
+		timer := time.AfterFunc(j.runTimeout, func() {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			// Runs are already ending when the scheduler stops
+			if j.stopping {
+				return
+			}
+			timedOut.Store(true)
+			run.endAs(runTimedOut)
+			run.log.event(run.ID, "timed out after %s", j.runTimeout)
+			j.end(run, done)
+		})
+		defer timer.Stop()
+	}
+
+	err = run.wait()
+	close(done)
+	j.mu.Lock()
+	delete(j.active, run)
+	stopping := j.stopping
+	j.mu.Unlock()
+
+	switch {
+	case stopping:
+		fmt.Printf("🛑 Agent execution stopped (run %s)\n", run.ID)
+	case timedOut.Load():
+		fmt.Printf("❌ Agent execution timed out after %s (run %s)\n", j.runTimeout, run.ID)
+	case err != nil:
+		fmt.Printf("❌ Agent execution failed (run %s): %v\n", run.ID, err)
+	case verbose:
+		fmt.Printf("✅ Agent execution completed successfully\n")
+	}
+}
+
+// jitterDelay returns a random delay before a run, below the jitter
+func (j *cronJob) jitterDelay() time.Duration {
+	if j.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(j.jitter)))
+}
+
+// end sends SIGTERM to a run's process group and kills it if it has not
+// exited after the grace period
+func (j *cronJob) end(run *agentRun, done chan struct{}) {
+	pid := run.cmd.Process.Pid
+	if err := terminateProcess(pid); err != nil {
+		killProcess(pid)
+		return
+	}
+	go func() {
+		select {
+		case <-done:
+		case <-time.After(j.gracePeriod):
+			run.log.event(run.ID, "did not exit within %s; killing it", j.gracePeriod)
+			killProcess(pid)
+		}
+	}()
+}
+
+// stop ends the runs in progress, drops a queued run and waits for them to
+// exit
+func (j *cronJob) stop() {
+	j.mu.Lock()
+	j.stopping = true
+	j.queued = false
+	close(j.stopped)
+	for run, done := range j.active {
+		run.endAs(runStopped)
+		run.log.event(run.ID, "stopping: the scheduler is shutting down")
+		j.end(run, done)
+	}
+	j.mu.Unlock()
+
+	j.wg.Wait()
+}
+
+// report tells about a scheduling decision on stdout and in the agent's log
+func (j *cronJob) report(icon, message string) {
+	fmt.Printf("[%s] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), icon, message)
+	j.log.event(noRunID, "%s", message)
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		timezone string
+		envTZ    string
+		wantLoc  string
+		wantErr  string
+	}{
+		{name: "local time by default", spec: "0 2 * * *", wantLoc: "Local"},
+		{name: "time zone flag", spec: "0 2 * * *", timezone: "America/New_York", wantLoc: "America/New_York"},
+		{name: "CRON_TZ environment", spec: "0 2 * * *", envTZ: "Asia/Tokyo", wantLoc: "Asia/Tokyo"},
+		{name: "flag wins over environment", spec: "0 2 * * *", timezone: "Europe/Berlin", envTZ: "Asia/Tokyo", wantLoc: "Europe/Berlin"},
+		{name: "CRON_TZ prefix wins over flag", spec: "CRON_TZ=Europe/London 0 2 * * *", timezone: "Asia/Tokyo", wantLoc: "Europe/London"},
+		{name: "six fields with seconds", spec: "30 0 2 * * *", wantLoc: "Local"},
+		{name: "descriptor", spec: "@hourly", timezone: "UTC", wantLoc: "UTC"},
+		{name: "every whole seconds", spec: "@every 90s", wantLoc: "Local"},
+		{name: "unknown time zone", spec: "0 2 * * *", timezone: "Mars/Olympus", wantErr: "invalid time zone"},
+		{name: "every below a second", spec: "@every 500ms", wantErr: "at least 1s"},
+		{name: "every with a fraction of a second", spec: "@every 1500ms", wantErr: "whole number of seconds"},
+		{name: "every without a duration", spec: "@every soon", wantErr: "invalid cron schedule"},
+		{name: "too few fields", spec: "0 2 *", wantErr: "invalid cron schedule"},
+		{name: "out of range", spec: "0 25 * * *", wantErr: "invalid cron schedule"},
+		{name: "never runs", spec: "0 0 30 2 *", wantErr: "never runs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CRON_TZ", tt.envTZ)
+
+			schedule, loc, err := parseSchedule(tt.spec, tt.timezone)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseSchedule(%q) error = %v, want one containing %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSchedule(%q) failed: %v", tt.spec, err)
+			}
+			if loc.String() != tt.wantLoc {
+				t.Errorf("parseSchedule(%q) location = %s, want %s", tt.spec, loc, tt.wantLoc)
+			}
+			if next := schedule.Next(time.Now()); next.IsZero() {
+				t.Errorf("parseSchedule(%q) never runs", tt.spec)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRunsInTimeZone(t *testing.T) {
+	t.Setenv("CRON_TZ", "")
+
+	schedule, loc, err := parseSchedule("0 2 * * *", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2am in New York is 7am UTC in winter and 6am UTC in summer
+	tests := []struct {
+		from time.Time
+		want time.Time
+	}{
+		{from: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), want: time.Date(2026, 1, 11, 7, 0, 0, 0, time.UTC)},
+		{from: time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC), want: time.Date(2026, 7, 11, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := schedule.Next(tt.from.In(loc)); !got.Equal(tt.want) {
+			t.Errorf("Next(%s) = %s, want %s", tt.from, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	schedule, loc, err := parseSchedule("@every 1h", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := nextRuns(schedule, loc, 3)
+	if len(runs) != 3 {
+		t.Fatalf("nextRuns returned %d runs, want 3", len(runs))
+	}
+	for i := 1; i < len(runs); i++ {
+		if d := runs[i].Sub(runs[i-1]); d != time.Hour {
+			t.Errorf("runs %d and %d are %s apart, want 1h", i-1, i, d)
+		}
+	}
+}
+
+func TestParseOverlapPolicy(t *testing.T) {
+	for _, value := range []string{"allow", "skip", "queue"} {
+		if policy, err := parseOverlapPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("parseOverlapPolicy(%q) = %q, %v", value, policy, err)
+		}
+	}
+	if _, err := parseOverlapPolicy("replace"); err == nil {
+		t.Error("parseOverlapPolicy(\"replace\") did not fail")
+	}
+}
+
+func TestJitterDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter time.Duration
+	}{
+		{name: "disabled", jitter: 0},
+		{name: "negative", jitter: -time.Second},
+		{name: "one nanosecond", jitter: 1},
+		{name: "minutes", jitter: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &cronJob{jitter: tt.jitter}
+			for i := 0; i < 1000; i++ {
+				delay := job.jitterDelay()
+				if delay < 0 || (tt.jitter <= 0 && delay != 0) || (tt.jitter > 0 && delay >= tt.jitter) {
+					t.Fatalf("jitterDelay() = %v with jitter %v", delay, tt.jitter)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// tarballSuffixes mark a template source as an archive rather than a git
+// repository
+var tarballSuffixes = []string{".tar.gz", ".tgz", ".tar"}
+
+// fetchTemplate makes a template source available as a local directory. The
+// source is a local directory, a tarball given as a path or http(s) URL, or a
+// git repository URL with an optional #ref naming a branch or tag. cleanup
+// removes anything that was downloaded.
+func fetchTemplate(ctx context.Context, source string) (dir string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	if info, err := os.Stat(source); err == nil {
+		if info.IsDir() {
+			return source, cleanup, nil
+		}
+		if !isTarball(source) {
+			return "", cleanup, fmt.Errorf("template source %s is neither a directory nor a tarball", source)
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "aphelion-template-")
+	if err != nil {
+		return "", cleanup, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+
+	switch {
+	case isTarball(source) && isHTTP(source):
+		err = downloadTarball(ctx, source, tmp)
+	case isTarball(source):
+		err = extractTarballFile(source, tmp)
+	case isGitURL(source):
+		err = cloneRepository(ctx, source, tmp)
+	default:
+		err = fmt.Errorf("template source %s not found", source)
+	}
+	if err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+
+	return unwrapDir(tmp), cleanup, nil
+}
+
+func isTarball(source string) bool {
+	lower := strings.ToLower(source)
+	for _, suffix := range tarballSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHTTP(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+func isGitURL(source string) bool {
+	for _, prefix := range []string{"git@", "git://", "ssh://", "file://"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return isHTTP(source)
+}
+
+// cloneRepository makes a shallow clone of url, checking out the branch or
+// tag after a '#' if there is one
+func cloneRepository(ctx context.Context, url, dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is required to fetch %s: %w", url, err)
+	}
+
+	args := []string{"-c", "advice.detachedHead=false", "clone", "--depth", "1", "--quiet"}
+	if i := strings.LastIndex(url, "#"); i >= 0 {
+		args = append(args, "--branch", url[i+1:])
+		url = url[:i]
+	}
+	args = append(args, url, dir)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return nil
+}
+
+func downloadTarball(ctx context.Context, url, dir string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return extractTarball(resp.Body, dir)
+}
+
+func extractTarballFile(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	return extractTarball(f, dir)
+}
+
+// extractTarball unpacks a tar archive, gzip compressed or not, into dir.
+// Entries that would end up outside dir are rejected, and links and other
+// special files are skipped.
+func extractTarball(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed to decompress template archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read template archive: %w", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if target == filepath.Clean(dir) {
+			continue
+		}
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("template archive entry %s is outside the archive", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755|0600)
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+		}
+	}
+}
+
+// unwrapDir returns the single directory dir contains, as archives and
+// GitHub tarballs usually wrap their files in one, or dir itself
+func unwrapDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err == nil {
+		return dir
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() || entries[0].Name() == ".git" {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is a file, directory or link written to a test archive
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+func buildTarball(t *testing.T, entries []tarEntry, compress bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Mode: 0644, Linkname: entry.linkname}
+		if entry.typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.body))
+		}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTarball(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []tarEntry
+		compress  bool
+		wantFiles map[string]string
+		wantErr   string
+	}{
+		{
+			name: "files and directories",
+			entries: []tarEntry{
+				{name: "template/", typeflag: tar.TypeDir},
+				{name: "template/aphelion-template.yaml", typeflag: tar.TypeReg, body: "name: demo\n"},
+				{name: "template/src/agent.py", typeflag: tar.TypeReg, body: "print('hi')\n"},
+			},
+			wantFiles: map[string]string{
+				"template/aphelion-template.yaml": "name: demo\n",
+				"template/src/agent.py":           "print('hi')\n",
+			},
+		},
+		{
+			name:     "gzip compressed",
+			compress: true,
+			entries: []tarEntry{
+				{name: "agent.sh", typeflag: tar.TypeReg, body: "#!/bin/sh\n"},
+			},
+			wantFiles: map[string]string{"agent.sh": "#!/bin/sh\n"},
+		},
+		{
+			name: "file without its directory entry",
+			entries: []tarEntry{
+				{name: "a/b/c.txt", typeflag: tar.TypeReg, body: "c"},
+			},
+			wantFiles: map[string]string{"a/b/c.txt": "c"},
+		},
+		{
+			name: "entry for the root itself",
+			entries: []tarEntry{
+				{name: "./", typeflag: tar.TypeDir},
+				{name: "./agent.js", typeflag: tar.TypeReg, body: "js"},
+			},
+			wantFiles: map[string]string{"agent.js": "js"},
+		},
+		{
+			name: "links are skipped",
+			entries: []tarEntry{
+				{name: "passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+				{name: "hard", typeflag: tar.TypeLink, linkname: "agent.py"},
+				{name: "agent.py", typeflag: tar.TypeReg, body: "py"},
+			},
+			wantFiles: map[string]string{"agent.py": "py"},
+		},
+		{
+			name: "parent directory traversal",
+			entries: []tarEntry{
+				{name: "../escape.txt", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantErr: "outside the archive",
+		},
+		{
+			name: "traversal inside a path",
+			entries: []tarEntry{
+				{name: "template/../../escape.txt", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantErr: "outside the archive",
+		},
+		{
+			name: "sibling directory with the same prefix",
+			entries: []tarEntry{
+				{name: "../out-evil/escape.txt", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantErr: "outside the archive",
+		},
+		{
+			name: "absolute path stays inside",
+			entries: []tarEntry{
+				{name: "/etc/cron.d/agent", typeflag: tar.TypeReg, body: "x"},
+			},
+			wantFiles: map[string]string{"etc/cron.d/agent": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractTarball(bytes.NewReader(buildTarball(t, tt.entries, tt.compress)), dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("extractTarball() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				// Nothing may be written next to the target directory
+				entries, _ := os.ReadDir(root)
+				if len(entries) != 1 {
+					t.Errorf("extractTarball() wrote outside %s: %v", dir, entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractTarball() failed: %v", err)
+			}
+
+			var got []string
+			filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					rel, _ := filepath.Rel(dir, path)
+					got = append(got, filepath.ToSlash(rel))
+				}
+				return nil
+			})
+			if len(got) != len(tt.wantFiles) {
+				t.Fatalf("extracted %v, want %d files", got, len(tt.wantFiles))
+			}
+			for name, want := range tt.wantFiles {
+				info, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("%s was not extracted: %v", name, err)
+				}
+				if !info.Mode().IsRegular() {
+					t.Errorf("%s is not a regular file", name)
+				}
+				data, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				if string(data) != want {
+					t.Errorf("%s = %q, want %q", name, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTarballRejectsGarbage(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not an archive", data: []byte(strings.Repeat("not a tar file ", 64))},
+		{name: "broken gzip", data: []byte{0x1f, 0x8b, 0x00, 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := extractTarball(bytes.NewReader(tt.data), t.TempDir()); err == nil {
+				t.Error("extractTarball() did not fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	stateFile = "state.json"
+	pidFile   = "agent.pid"
+	// logFile is the default agent log, for projects without logging.file
+	logFile = "agent.log"
+	// supervisorLogFile receives the output of the background supervisor
+	supervisorLogFile = "supervisor.log"
+)
+
+// Agent statuses shown by 'aphelion agent ps'
+const (
+	statusRunning = "running"
+	statusStopped = "stopped"
+	statusFailed  = "failed"
+)
+
+// agentState describes an agent started with 'aphelion agent run --daemon'.
+// It is kept in ~/.aphelion/agents/<name>/ together with the pid and output
+// of its supervisor.
+type agentState struct {
+	Name string `json:"name"`
+	// PID is the supervisor process, AgentPID the agent it runs
+	PID      int `json:"pid"`
+	AgentPID int `json:"agent_pid,omitempty"`
+	// PIDStart and AgentPIDStart identify when those processes started, so
+	// that a pid reused after a crash or reboot is not taken for them
+	PIDStart      string `json:"pid_start,omitempty"`
+	AgentPIDStart string `json:"agent_pid_start,omitempty"`
+	Restarts      int    `json:"restarts"`
+	AgentFile     string `json:"agent_file"`
+	// Dir and Command are the working directory and arguments of the
+	// 'aphelion' invocation that started the agent, used to restart it
+	Dir     string   `json:"dir"`
+	Command []string `json:"command"`
+	// LogFile is the agent's log, SupervisorLog the supervisor's output
+	LogFile       string        `json:"log_file"`
+	SupervisorLog string        `json:"supervisor_log"`
+	GracePeriod   time.Duration `json:"grace_period"`
+	StartedAt     time.Time     `json:"started_at"`
+	StoppedAt     *time.Time    `json:"stopped_at,omitempty"`
+	// Error is why the supervisor gave up, empty if it stopped normally
+	Error string `json:"error,omitempty"`
+}
+
+// agentsDir returns the directory agent state is kept in
+func agentsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(home, ".aphelion", "agents"), nil
+}
+
+// agentDir returns the state directory of the named agent
+func agentDir(name string) (string, error) {
+	dir, err := agentsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// loadState reads the state of the named agent
+func loadState(name string) (*agentState, error) {
+	dir, err := agentDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no agent named %q; see 'aphelion agent ps'", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state of agent %s: %w", name, err)
+	}
+
+	state := &agentState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state of agent %s: %w", name, err)
+	}
+	return state, nil
+}
+
+// loadStates reads the state of every agent, sorted by name. Directories
+// without a readable state are skipped.
+func loadStates() ([]*agentState, error) {
+	dir, err := agentsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	var states []*agentState
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if state, err := loadState(entry.Name()); err == nil {
+			states = append(states, state)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states, nil
+}
+
+// save writes the state, replacing the previous one atomically so readers
+// never see a partial file
+func (s *agentState) save() error {
+	dir, err := agentDir(s.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create agent directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode agent state: %w", err)
+	}
+
+	tmp := filepath.Join(dir, stateFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write agent state: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, stateFile)); err != nil {
+		return fmt.Errorf("failed to write agent state: %w", err)
+	}
+	return nil
+}
+
+// writePID records the supervisor pid for tools outside the CLI
+func (s *agentState) writePID() error {
+	dir, err := agentDir(s.Name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, pidFile), []byte(strconv.Itoa(s.PID)+"\n"), 0600)
+}
+
+func (s *agentState) removePID() {
+	if dir, err := agentDir(s.Name); err == nil {
+		os.Remove(filepath.Join(dir, pidFile))
+	}
+}
+
+// running reports whether the agent's supervisor is still alive
+func (s *agentState) running() bool {
+	return s.StoppedAt == nil && sameProcess(s.PID, s.PIDStart)
+}
+
+// agentRunning reports whether the agent process last started by the
+// supervisor is still alive
+func (s *agentState) agentRunning() bool {
+	return sameProcess(s.AgentPID, s.AgentPIDStart)
+}
+
+// clearStale marks an agent as stopped whose supervisor is gone without
+// having recorded it, as after a crash or reboot, so that its pid is not
+// signalled when another process reuses it. It reports whether the state
+// was stale.
+func (s *agentState) clearStale() (bool, error) {
+	if s.StoppedAt != nil || s.running() {
+		return false, nil
+	}
+	// The supervisor may have recorded its stop, or a new one started,
+	// since the state was read
+	if current, err := loadState(s.Name); err == nil && (current.StoppedAt != nil || current.PID != s.PID) {
+		*s = *current
+		return false, nil
+	}
+
+	stopped := time.Now()
+	s.StoppedAt = &stopped
+	s.AgentPID, s.AgentPIDStart = 0, ""
+	s.Error = fmt.Sprintf("supervisor (pid %d) ended without recording why, as after a crash or reboot", s.PID)
+	s.removePID()
+	return true, s.save()
+}
+
+// sameProcess reports whether pid is alive and still the process that
+// started at start, rather than an unrelated one that reused the pid. A
+// process whose start is unknown is taken at its pid.
+func sameProcess(pid int, start string) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	if start == "" {
+		return true
+	}
+	current, err := processStart(pid)
+	return err != nil || current == start
+}
+
+func (s *agentState) status() string {
+	switch {
+	case s.running():
+		return statusRunning
+	case s.Error != "":
+		return statusFailed
+	}
+	return statusStopped
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// exitedPID returns the pid of a process that has already exited
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestSameProcess(t *testing.T) {
+	self := os.Getpid()
+	start, err := processStart(self)
+	if err != nil {
+		t.Fatalf("processStart failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		pid   int
+		start string
+		want  bool
+	}{
+		{name: "same process", pid: self, start: start, want: true},
+		{name: "unknown start", pid: self, start: "", want: true},
+		{name: "pid reused by another process", pid: self, start: start + "0", want: false},
+		{name: "exited process", pid: exitedPID(t), start: "", want: false},
+		{name: "no pid", pid: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameProcess(tt.pid, tt.start); got != tt.want {
+				t.Errorf("sameProcess(%d, %q) = %v, want %v", tt.pid, tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearStale(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	self := os.Getpid()
+	start, err := processStart(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := time.Now()
+
+	tests := []struct {
+		name      string
+		state     agentState
+		wantStale bool
+	}{
+		{name: "running supervisor", state: agentState{PID: self, PIDStart: start}},
+		{name: "stopped agent", state: agentState{PID: exitedPID(t), StoppedAt: &stopped}},
+		{name: "supervisor gone", state: agentState{PID: exitedPID(t), AgentPID: self}, wantStale: true},
+		{name: "pid reused", state: agentState{PID: self, PIDStart: start + "0", AgentPID: self}, wantStale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			state.Name = "watcher"
+			if err := state.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			stale, err := state.clearStale()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stale != tt.wantStale {
+				t.Fatalf("clearStale() = %v, want %v", stale, tt.wantStale)
+			}
+			if !stale {
+				return
+			}
+
+			saved, err := loadState("watcher")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.StoppedAt == nil || saved.Error == "" || saved.AgentPID != 0 {
+				t.Errorf("saved state = %+v, want it stopped with an error and no agent pid", saved)
+			}
+			if saved.running() || saved.status() != statusFailed {
+				t.Errorf("status() = %s, want %s", saved.status(), statusFailed)
+			}
+		})
+	}
+}
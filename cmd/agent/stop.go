@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+)
+
+// stopMargin is added to an agent's grace period when waiting for its
+// supervisor to exit, for the supervisor to shut down itself
+const stopMargin = 5 * time.Second
+
+func newStopCmd() *cobra.Command {
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "stop <name>",
+		Short: "Stop a background agent",
+		Long: `Stop an agent started with 'aphelion agent run --daemon'. The agent receives
+SIGTERM and is killed if it has not exited after its grace period. Should the
+agent and its supervisor still run after --wait, both are killed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := loadState(args[0])
+			if err != nil {
+				return err
+			}
+			if err := clearStaleState(state); err != nil {
+				return err
+			}
+			if !state.running() {
+				utils.PrintInfo("Agent %s is not running", state.Name)
+				return nil
+			}
+			return stopAgent(state, wait)
+		},
+	}
+
+	cmd.Flags().DurationVar(&wait, "wait", 0, "time to wait for the agent to stop before killing it (default is its grace period plus 5s)")
+
+	return cmd
+}
+
+func newRestartCmd() *cobra.Command {
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "restart <name>",
+		Short: "Restart a background agent",
+		Long: `Stop a background agent if it is running and start it again with the
+arguments it was originally started with. As with 'aphelion agent stop', the
+agent and its supervisor are killed if they still run after --wait.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := loadState(args[0])
+			if err != nil {
+				return err
+			}
+			if err := clearStaleState(state); err != nil {
+				return err
+			}
+			if state.running() {
+				if err := stopAgent(state, wait); err != nil {
+					return err
+				}
+			}
+			return startDetached(state.Name, state.Command, state.Dir)
+		},
+	}
+
+	cmd.Flags().DurationVar(&wait, "wait", 0, "time to wait for the agent to stop before killing it (default is its grace period plus 5s)")
+
+	return cmd
+}
+
+// clearStaleState records an agent whose supervisor is gone as stopped,
+// telling the user, so that its pid is never signalled
+func clearStaleState(state *agentState) error {
+	stale, err := state.clearStale()
+	if stale {
+		utils.PrintWarning("Agent %s was not stopped cleanly: its supervisor (pid %d) is gone, as after a crash or reboot", state.Name, state.PID)
+	}
+	return err
+}
+
+// stopAgent signals the supervisor of a background agent, which stops the
+// agent, and waits for it to exit. The supervisor's pid is checked against
+// the process that started before every signal, so a pid reused after the
+// supervisor exited is left alone.
+func stopAgent(state *agentState, wait time.Duration) error {
+	if wait == 0 {
+		wait = state.GracePeriod + stopMargin
+	}
+
+	fmt.Printf("🛑 Stopping agent %s (pid %d)...\n", state.Name, state.PID)
+	if err := terminateProcess(state.PID); err != nil {
+		wait = 0
+	}
+
+	deadline := time.Now().Add(wait)
+	for state.running() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if state.running() {
+		if wait > 0 {
+			utils.PrintWarning("Agent %s did not stop within %s, killing it", state.Name, wait)
+		}
+		agentRunning := state.agentRunning()
+		killProcess(state.PID)
+		if agentRunning {
+			killProcess(state.AgentPID)
+		}
+		for i := 0; i < 50 && state.running(); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if state.running() {
+			return fmt.Errorf("failed to stop agent %s (pid %d)", state.Name, state.PID)
+		}
+
+		// The supervisor had no chance to record that it stopped
+		stopped := time.Now()
+		state.StoppedAt = &stopped
+		state.AgentPID, state.AgentPIDStart = 0, ""
+		state.removePID()
+		if err := state.save(); err != nil {
+			return err
+		}
+	}
+
+	utils.PrintSuccess("Agent %s stopped", state.Name)
+	return nil
+}
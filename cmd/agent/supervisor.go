@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// restartPolicy decides whether the supervisor starts an agent again after it
+// exits
+type restartPolicy string
+
+const (
+	restartNever     restartPolicy = "never"
+	restartOnFailure restartPolicy = "on-failure"
+	restartAlways    restartPolicy = "always"
+)
+
+func parseRestartPolicy(value string) (restartPolicy, error) {
+	switch policy := restartPolicy(value); policy {
+	case restartNever, restartOnFailure, restartAlways:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid restart policy %q: must be one of never, on-failure or always", value)
+}
+
+const (
+	// minUptime is how long a run must last to count as healthy. Healthy
+	// runs reset the restart backoff.
+	minUptime = 10 * time.Second
+	// crashLoopFailures is the number of failed runs in a row, each shorter
+	// than minUptime, after which the agent is considered crash looping
+	crashLoopFailures = 5
+)
+
+// supervisor runs an agent in the foreground, restarting it according to its
+// policy, and stops it when the CLI is interrupted
+type supervisor struct {
+	agentFile string
+	policy    restartPolicy
+	// maxRestarts limits the number of restarts; zero means no limit
+	maxRestarts int
+	// restartDelay is the wait before the first restart. It doubles with
+	// every failed run that is shorter than minUptime, up to maxRestartDelay.
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+	// gracePeriod is how long the agent has to exit after SIGTERM before it
+	// is killed
+	gracePeriod time.Duration
+
+	// log captures the agent's output and the supervisor's decisions;
+	// output is also copied to stdout and stderr when they are set
+	log            *agentLog
+	stdout, stderr io.Writer
+	// onStart is called with the pid of every agent process started
+	onStart func(pid, restarts int)
+}
+
+func (s *supervisor) run() error {
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	restarts, failures := 0, 0
+	delay := s.restartDelay
+
+	for {
+		run := s.log.newRun(s.agentFile, s.stdout, s.stderr)
+		run.trigger = triggerDaemon
+		// The agent gets its own process group so signals reach everything
+		// it started, such as the binary built by 'go run'
+		setProcessGroup(run.cmd)
+
+		if err := run.start(); err != nil {
+			return fmt.Errorf("failed to start agent: %w", err)
+		}
+		s.printf("▶️", "Agent started (pid %d, run %s)", run.cmd.Process.Pid, run.ID)
+		if s.onStart != nil {
+			s.onStart(run.cmd.Process.Pid, restarts)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- run.wait() }()
+
+		var err error
+		select {
+		case err = <-done:
+		case sig := <-sigChan:
+			s.logf(run.ID, "🛑", "Received signal %v, stopping agent...", sig)
+			s.stop(run, done, sigChan)
+			return nil
+		}
+
+		uptime := run.uptime()
+		if !s.shouldRestart(err) {
+			if err != nil {
+				return fmt.Errorf("agent execution failed: %w", err)
+			}
+			s.printf("✅", "Agent exited after %s", uptime)
+			return nil
+		}
+
+		if err == nil || uptime >= minUptime {
+			delay, failures = s.restartDelay, 0
+		} else {
+			failures++
+			if failures >= crashLoopFailures {
+				return fmt.Errorf("agent is crash looping: it failed %d times in a row within %s of starting (last: %s)", failures, minUptime, describeExit(err))
+			}
+		}
+		if s.maxRestarts > 0 && restarts >= s.maxRestarts {
+			if err == nil {
+				s.logf(run.ID, "✅", "Agent exited after %s; not restarting after %d restarts", uptime, restarts)
+				return nil
+			}
+			return fmt.Errorf("agent %s; giving up after %d restarts", describeExit(err), restarts)
+		}
+
+		restarts++
+		s.logf(run.ID, "🔁", "Agent %s after %s, restarting in %s (restart %d)", describeExit(err), uptime, delay, restarts)
+		select {
+		case <-time.After(delay):
+		case sig := <-sigChan:
+			s.logf(run.ID, "🛑", "Received signal %v, not restarting agent", sig)
+			return nil
+		}
+
+		if err != nil {
+			delay *= 2
+			if delay > s.maxRestartDelay {
+				delay = s.maxRestartDelay
+			}
+		}
+	}
+}
+
+func (s *supervisor) shouldRestart(err error) bool {
+	switch s.policy {
+	case restartAlways:
+		return true
+	case restartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// stop asks the agent to exit with SIGTERM and kills it once the grace
+// period is over, or right away on a second signal
+func (s *supervisor) stop(run *agentRun, done <-chan error, sigChan <-chan os.Signal) {
+	pid := run.cmd.Process.Pid
+	run.endAs(runStopped)
+	if err := terminateProcess(pid); err != nil {
+		killProcess(pid)
+		<-done
+		return
+	}
+
+	timer := time.NewTimer(s.gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		s.logf(run.ID, "✅", "Agent stopped")
+		return
+	case <-timer.C:
+		s.logf(run.ID, "⚠️", "Agent did not stop within %s, killing it", s.gracePeriod)
+	case sig := <-sigChan:
+		s.logf(run.ID, "⚠️", "Received signal %v again, killing agent", sig)
+	}
+	killProcess(pid)
+	<-done
+}
+
+// describeExit explains how a run ended
+func describeExit(err error) string {
+	if err == nil {
+		return "exited successfully"
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return fmt.Sprintf("was killed by %v", status.Signal())
+		}
+		return fmt.Sprintf("exited with status %d", exitErr.ExitCode())
+	}
+	return fmt.Sprintf("failed: %v", err)
+}
+
+// logf reports what the supervisor does, on stdout and in the agent's log
+// under the run it concerns
+func (s *supervisor) logf(runID, icon, format string, args ...interface{}) {
+	s.printf(icon, format, args...)
+	s.log.event(runID, format, args...)
+}
+
+// printf reports what the supervisor does on stdout only, for what the
+// agent's log already records
+func (s *supervisor) printf(icon, format string, args ...interface{}) {
+	fmt.Printf("[%s] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), icon, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templatesFS holds the built-in agent project templates. Every file ends in
+// .tmpl so the Go template is not compiled into the CLI; files under common
+// are added to every project.
+//
+//go:embed all:templates
+var templatesFS embed.FS
+
+const commonTemplate = "common"
+
+// manifestFile describes a template fetched with 'aphelion agent init --from'
+const manifestFile = "aphelion-template.yaml"
+
+// stateFiles are written by the agent at runtime. They are created empty and
+// kept as they are when a project is initialized again.
+var stateFiles = map[string]bool{
+	".aphelion/session": true,
+}
+
+// agentTemplate describes a language template for 'aphelion agent init'
+type agentTemplate struct {
+	Description string
+	// Entrypoint is the file passed to 'aphelion agent run'
+	Entrypoint string
+	// Setup lists commands to run before the first start
+	Setup []string
+}
+
+var agentTemplates = map[string]agentTemplate{
+	"python": {Description: "Python 3 agent using requests", Entrypoint: "agent.py", Setup: []string{"pip install -r requirements.txt"}},
+	"node":   {Description: "Node.js 18+ agent using the built-in fetch", Entrypoint: "agent.js"},
+	"go":     {Description: "Go agent using only the standard library", Entrypoint: "agent.go"},
+	"shell":  {Description: "Bash agent using curl and jq", Entrypoint: "agent.sh"},
+}
+
+// templateManifest is the aphelion-template.yaml of a template source
+type templateManifest struct {
+	Name        string             `yaml:"name"`
+	Description string             `yaml:"description"`
+	Entrypoint  string             `yaml:"entrypoint"`
+	Setup       []string           `yaml:"setup"`
+	Variables   []templateVariable `yaml:"variables"`
+	// Exclude lists files and directories, as path.Match patterns, that are
+	// not copied to the project
+	Exclude []string `yaml:"exclude"`
+}
+
+// templateVariable is a value asked for when the project is created and
+// available to the template files as {{.<name>}}
+type templateVariable struct {
+	Name        string `yaml:"name"`
+	Prompt      string `yaml:"prompt"`
+	Description string `yaml:"description"`
+	// Default may refer to other variables, e.g. "{{.Name}}-agent"
+	Default  string   `yaml:"default"`
+	Required bool     `yaml:"required"`
+	Choices  []string `yaml:"choices"`
+}
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// builtinVariables are set by 'aphelion agent init' itself
+var builtinVariables = []string{"Name", "Description", "Template", "Entrypoint", "APIURL"}
+
+// renderedFile is a project file produced from a template
+type renderedFile struct {
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// templateNames returns the available templates, sorted
+func templateNames() []string {
+	names := make([]string, 0, len(agentTemplates))
+	for name := range agentTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readManifest loads and checks the manifest of a template source. Sources
+// without one are rendered with the built-in variables only.
+func readManifest(fsys fs.FS) (*templateManifest, error) {
+	manifest := &templateManifest{}
+
+	raw, err := fs.ReadFile(fsys, manifestFile)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", manifestFile, err)
+	}
+	if err := yaml.Unmarshal(raw, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestFile, err)
+	}
+
+	for _, v := range manifest.Variables {
+		if !variableNamePattern.MatchString(v.Name) {
+			return nil, fmt.Errorf("invalid variable name %q in %s", v.Name, manifestFile)
+		}
+		for _, builtin := range builtinVariables {
+			if v.Name == builtin {
+				return nil, fmt.Errorf("variable %s in %s is set by aphelion and cannot be redefined", v.Name, manifestFile)
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// excluded reports whether a template file is left out of the project
+func (m *templateManifest) excluded(p string) bool {
+	if p == manifestFile || p == ".git" || strings.HasPrefix(p, ".git/") {
+		return true
+	}
+	for _, pattern := range m.Exclude {
+		pattern = strings.TrimSuffix(pattern, "/")
+		for dir := p; dir != "."; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderBuiltin renders the common files and those of the named built-in
+// template
+func renderBuiltin(name string, data map[string]interface{}) ([]renderedFile, error) {
+	var files []renderedFile
+	for _, dir := range []string{commonTemplate, name} {
+		fsys, err := fs.Sub(templatesFS, path.Join("templates", dir))
+		if err != nil {
+			return nil, err
+		}
+		rendered, err := renderFS(fsys, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, rendered...)
+	}
+	return files, nil
+}
+
+// renderFS renders every file of a template. Files ending in .tmpl are
+// executed with data and lose the suffix; other files are copied as they
+// are. Paths are relative to the project directory.
+func renderFS(fsys fs.FS, data map[string]interface{}, skip func(string) bool) ([]renderedFile, error) {
+	entrypoint, _ := data["Entrypoint"].(string)
+
+	var files []renderedFile
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == "." {
+			return err
+		}
+		if skip != nil && skip(p) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("template file %s is not a regular file", p)
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		rel := p
+		if strings.HasSuffix(p, ".tmpl") {
+			rel = strings.TrimSuffix(p, ".tmpl")
+			if content, err = renderString(p, string(content), data); err != nil {
+				return err
+			}
+		}
+
+		mode := os.FileMode(0644)
+		if info, err := d.Info(); err == nil && info.Mode()&0111 != 0 {
+			mode = 0755
+		}
+		if rel == entrypoint {
+			mode = 0755
+		}
+
+		files = append(files, renderedFile{Path: rel, Content: content, Mode: mode})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func renderString(name, text string, data map[string]interface{}) ([]byte, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -33,13 +33,9 @@ func newSessionsCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			params := map[string]string{
-				"timeframe": timeframe,
-				"user_only": fmt.Sprintf("%t", userOnly),
-			}
-
-			var analytics api.Analytics
-			if err := client.GetWithQuery("/analytics/sessions", params, &analytics); err != nil {
+			opts := api.AnalyticsOptions{Timeframe: timeframe, UserOnly: userOnly}
+			analytics, err := client.Analytics().Sessions(cmd.Context(), opts)
+			if err != nil {
 				return fmt.Errorf("failed to get session analytics: %w", err)
 			}
 
@@ -33,13 +33,9 @@ func newToolsCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			params := map[string]string{
-				"timeframe": timeframe,
-				"user_only": fmt.Sprintf("%t", userOnly),
-			}
-
-			var analytics api.Analytics
-			if err := client.GetWithQuery("/analytics/tools", params, &analytics); err != nil {
+			opts := api.AnalyticsOptions{Timeframe: timeframe, UserOnly: userOnly}
+			analytics, err := client.Analytics().Tools(cmd.Context(), opts)
+			if err != nil {
 				return fmt.Errorf("failed to get tool analytics: %w", err)
 			}
 
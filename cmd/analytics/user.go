@@ -32,12 +32,8 @@ func newUserCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			params := map[string]string{
-				"timeframe": timeframe,
-			}
-
-			var analytics api.Analytics
-			if err := client.GetWithQuery("/analytics/user", params, &analytics); err != nil {
+			analytics, err := client.Analytics().User(cmd.Context(), api.AnalyticsOptions{Timeframe: timeframe})
+			if err != nil {
 				return fmt.Errorf("failed to get user analytics: %w", err)
 			}
 
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newAccountsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List the accounts logged in to the profile",
+		Long: `List the accounts logged in to the active profile.
+
+Each 'aphelion auth login' as a different user adds an account. Switch between
+them with 'aphelion auth switch', or use one for a single command with
+--account.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accounts := config.ListAccounts()
+			printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), "Current", "Account", "User", "Email", "Type", "Last Login")
+			if err != nil {
+				return err
+			}
+			printer.Empty("No accounts in profile %s. Run 'aphelion auth login' to add one", config.GetProfileName())
+
+			for _, account := range accounts {
+				current := ""
+				if account.Current {
+					current = "*"
+				}
+
+				kind := "token"
+				switch {
+				case account.Service:
+					kind = "service"
+				case account.Refreshable:
+					kind = "oauth"
+				}
+
+				lastLogin := ""
+				if !account.LastLogin.IsZero() {
+					lastLogin = account.LastLogin.Local().Format("2006-01-02 15:04")
+				}
+
+				if err := printer.Print(map[string]interface{}{
+					"Current":    current,
+					"Account":    account.Name,
+					"User":       account.Username,
+					"Email":      account.Email,
+					"Type":       kind,
+					"Last Login": lastLogin,
+				}); err != nil {
+					return err
+				}
+			}
+
+			return printer.Close()
+		},
+	}
+
+	return cmd
+}
+
+func newSwitchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch <account>",
+		Short: "Switch the current account",
+		Long:  "Make the named account the default for subsequent commands in the active profile",
+		Args:  cobra.ExactArgs(1),
+		Example: `  # Switch to another account
+  aphelion auth switch alice
+
+  # Use an account for a single command instead
+  aphelion registry list --account alice`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.UseAccount(args[0]); err != nil {
+				return fmt.Errorf("failed to switch account: %w", err)
+			}
+
+			utils.PrintSuccess("Switched to account %s", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
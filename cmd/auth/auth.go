@@ -15,7 +15,11 @@ func NewAuthCmd() *cobra.Command {
 	cmd.AddCommand(newRegisterCmd())
 	cmd.AddCommand(newProfileCmd())
 	cmd.AddCommand(newLogoutCmd())
+	cmd.AddCommand(newAccountsCmd())
+	cmd.AddCommand(newSwitchCmd())
 	cmd.AddCommand(newOAuthCmd())
+	cmd.AddCommand(newTokenCmd())
+	cmd.AddCommand(newStatusCmd())
 
 	return cmd
 }
\ No newline at end of file
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"syscall"
@@ -18,7 +20,9 @@ import (
 
 func newLoginCmd() *cobra.Command {
 	var username, password string
-	var noLaunchBrowser bool
+	var noLaunchBrowser, device, withToken, clientCredentials bool
+	var clientID string
+	var callbackPort int
 
 	cmd := &cobra.Command{
 		Use:   "login",
@@ -30,6 +34,18 @@ func newLoginCmd() *cobra.Command {
   # Login without launching browser (copy URL manually)
   aphelion auth login --no-launch-browser
 
+  # Listen for the browser redirect on another port
+  aphelion auth login --callback-port 9000
+
+  # Login on a headless machine by approving a code on another device
+  aphelion auth login --device
+
+  # Login with a personal access token read from stdin, e.g. in CI
+  echo "$APHELION_PAT" | aphelion auth login --with-token
+
+  # Login a service account for scheduled agents
+  APHELION_CLIENT_ID=my-agent APHELION_CLIENT_SECRET=... aphelion auth login --client-credentials
+
   # Login with username/password (legacy)
   aphelion auth login --username myuser
 
@@ -38,29 +54,57 @@ func newLoginCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// If username is provided, use legacy authentication
 			if username != "" {
-				return legacyLogin(username, password)
+				return legacyLogin(cmd.Context(), username, password)
+			}
+
+			if withToken {
+				return tokenLogin(cmd.Context(), os.Stdin)
+			}
+
+			if clientCredentials {
+				return clientCredentialsLogin(cmd.Context(), clientID)
+			}
+
+			if device {
+				return deviceLogin(cmd.Context())
 			}
 
 			// Use OAuth flow
-			return oauthLogin(noLaunchBrowser)
+			return oauthLogin(cmd.Context(), noLaunchBrowser, callbackPort, cmd.Flags().Changed("callback-port"))
 		},
 	}
 
 	cmd.Flags().StringVarP(&username, "username", "u", "", "username for legacy authentication")
 	cmd.Flags().StringVarP(&password, "password", "p", "", "password for legacy authentication (not recommended, use interactive mode)")
 	cmd.Flags().BoolVar(&noLaunchBrowser, "no-launch-browser", false, "print auth URL instead of opening browser")
+	cmd.Flags().IntVar(&callbackPort, "callback-port", authPkg.DefaultCallbackPort, "local port for the login redirect; when not set, a free port is used if the default is taken")
+	cmd.Flags().BoolVar(&device, "device", false, "login with a code approved on another device (for headless machines)")
+	cmd.MarkFlagsMutuallyExclusive("device", "no-launch-browser")
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "read a personal access token from standard input")
+	cmd.Flags().BoolVar(&clientCredentials, "client-credentials", false, "login a service account with its client ID and secret")
+	cmd.Flags().StringVar(&clientID, "client-id", "", "client ID of the service account (default $"+config.ClientIDEnv+")")
+	cmd.MarkFlagsMutuallyExclusive("device", "username", "with-token", "client-credentials")
+	cmd.MarkFlagsMutuallyExclusive("no-launch-browser", "with-token", "client-credentials")
 
 	return cmd
 }
 
-func oauthLogin(noLaunchBrowser bool) error {
+// getOAuthConfig fetches the OAuth configuration from the gateway
+func getOAuthConfig(ctx context.Context) (*authPkg.OAuthConfig, error) {
 	client := api.NewClient()
-	
-	// Get OAuth configuration from API
+
 	utils.PrintInfo("Getting authentication configuration...")
-	var oauthConfig authPkg.OAuthConfig
-	if err := client.Get("/auth/info", &oauthConfig); err != nil {
-		return fmt.Errorf("failed to get OAuth configuration: %w", err)
+	authInfo, err := client.Auth().Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth configuration: %w", err)
+	}
+
+	oauthConfig := authPkg.OAuthConfig{
+		Domain:      authInfo.Domain,
+		ClientID:    authInfo.ClientID,
+		Audience:    authInfo.Audience,
+		RedirectURI: authInfo.RedirectURI,
+		AuthURL:     authInfo.AuthURL,
 	}
 
 	// Set default client_id if not provided by API
@@ -68,40 +112,42 @@ func oauthLogin(noLaunchBrowser bool) error {
 		oauthConfig.ClientID = "UbXxpQBSr9AsqpS2ln2jzmsmamromaFC" // Correct client_id
 	}
 
-	// Update redirect URI to use localhost
-	oauthConfig.RedirectURI = "http://localhost:8765/callback"
-	
+	return &oauthConfig, nil
+}
+
+func oauthLogin(ctx context.Context, noLaunchBrowser bool, callbackPort int, fixedPort bool) error {
+	oauthConfig, err := getOAuthConfig(ctx)
+	if err != nil {
+		return err
+	}
+	oauthConfig.CallbackPort = callbackPort
+	oauthConfig.FixedCallbackPort = fixedPort
+	oauthConfig.RedirectURI = authPkg.CallbackURL(callbackPort)
+
 	if noLaunchBrowser {
-		// Generate PKCE for manual mode
-		codeVerifier, codeChallenge, err := authPkg.GeneratePKCE()
-		if err != nil {
-			return fmt.Errorf("failed to generate PKCE parameters: %w", err)
+		// Generate PKCE and state for manual mode
+		if err := authPkg.PrepareAuthorization(oauthConfig); err != nil {
+			return err
 		}
-		oauthConfig.CodeVerifier = codeVerifier
-		oauthConfig.CodeChallenge = codeChallenge
-		
-		// Build auth URL with PKCE
-		authURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=openid%%20profile%%20email&audience=%s&code_challenge=%s&code_challenge_method=S256",
-			oauthConfig.AuthURL,
-			oauthConfig.ClientID,
-			oauthConfig.RedirectURI,
-			oauthConfig.Audience,
-			oauthConfig.CodeChallenge,
-		)
-		
-		fmt.Printf("\nPlease open the following URL in your browser:\n\n%s\n\n", authURL)
-		fmt.Print("Enter the authorization code: ")
-		
-		var code string
-		if _, err := fmt.Scanln(&code); err != nil {
+
+		fmt.Printf("\nPlease open the following URL in your browser:\n\n%s\n\n", authPkg.AuthorizeURL(oauthConfig))
+		fmt.Print("Enter the authorization code, or the full URL you were redirected to: ")
+
+		var input string
+		if _, err := fmt.Scanln(&input); err != nil {
 			return fmt.Errorf("failed to read authorization code: %w", err)
 		}
-		
-		return completeOAuthFlow(&oauthConfig, code)
+
+		code, err := authPkg.VerifyCallback(strings.TrimSpace(input), oauthConfig.State)
+		if err != nil {
+			return err
+		}
+
+		return completeOAuthFlow(ctx, oauthConfig, code)
 	}
 
 	// Start OAuth flow with browser
-	result, err := authPkg.StartOAuthFlow(&oauthConfig)
+	result, err := authPkg.StartOAuthFlow(ctx, oauthConfig)
 	if err != nil {
 		return fmt.Errorf("OAuth flow failed: %w", err)
 	}
@@ -110,39 +156,190 @@ func oauthLogin(noLaunchBrowser bool) error {
 		return fmt.Errorf("authentication failed: %s", result.Error)
 	}
 
-	return completeOAuthFlow(&oauthConfig, result.Code)
+	return completeOAuthFlow(ctx, oauthConfig, result.Code)
+}
+
+// deviceLogin runs the device authorization flow: the user approves a code
+// in a browser on any device while the CLI polls for the token
+func deviceLogin(ctx context.Context) error {
+	oauthConfig, err := getOAuthConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	device, err := authPkg.RequestDeviceCode(ctx, oauthConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nOn any device, open:\n\n  %s\n\nand enter the code: %s\n\n", device.VerificationURI, device.UserCode)
+	if device.VerificationURIComplete != "" {
+		fmt.Printf("Or open this URL, which includes the code:\n\n  %s\n\n", device.VerificationURIComplete)
+	}
+
+	spinner := utils.NewSpinner("Waiting for the login to be approved...")
+	spinner.Start()
+	tokenResp, err := authPkg.PollDeviceToken(ctx, oauthConfig, device)
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+
+	return saveOAuthLogin(ctx, tokenResp, authPkg.IssuerURL(oauthConfig)+"/oauth/token", oauthConfig.ClientID)
 }
 
-func completeOAuthFlow(oauthConfig *authPkg.OAuthConfig, code string) error {
+func completeOAuthFlow(ctx context.Context, oauthConfig *authPkg.OAuthConfig, code string) error {
 	spinner := utils.NewSpinner("Exchanging authorization code for token...")
 	spinner.Start()
 
 	// Exchange code for token
-	tokenResp, err := authPkg.ExchangeCodeForToken(oauthConfig, code)
+	tokenResp, err := authPkg.ExchangeCodeForToken(ctx, oauthConfig, code)
+	spinner.Stop()
 	if err != nil {
-		spinner.Stop()
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
 
+	return saveOAuthLogin(ctx, tokenResp, authPkg.TokenURL(oauthConfig), oauthConfig.ClientID)
+}
+
+// saveOAuthLogin registers the user with the gateway and saves the tokens
+// from an OAuth login, with the refresh token when one was issued
+func saveOAuthLogin(ctx context.Context, tokenResp *authPkg.TokenResponse, tokenURL, clientID string) error {
 	// Create/get user in Aphelion database
-	userInfo, err := authPkg.CreateOrGetUser(config.GetAPIUrl(), tokenResp.AccessToken)
+	userInfo, err := authPkg.CreateOrGetUser(ctx, config.GetAPIUrl(), tokenResp.AccessToken)
 	if err != nil {
-		spinner.Stop()
 		return fmt.Errorf("failed to create/get user profile: %w", err)
 	}
 
+	tokens := config.TokenSet{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		Expiry:       tokenResp.Expiry(),
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+	}
+	if err := config.SetAuthTokens(tokens, userInfo.Sub, userInfo.Email, userInfo.Name); err != nil {
+		return fmt.Errorf("failed to save authentication: %w", err)
+	}
+
+	utils.PrintSuccess("Successfully authenticated as %s (%s)", userInfo.Name, userInfo.Email)
+	return nil
+}
+
+// tokenLogin saves a personal access token read from r after checking it
+// against the gateway
+func tokenLogin(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("no token provided on standard input")
+	}
+
+	client := api.NewClient()
+	client.SetHeader("Authorization", "Bearer "+token)
+
+	user, err := client.Auth().Profile(ctx)
+	if err != nil {
+		return fmt.Errorf("token rejected by the gateway: %w", err)
+	}
+
+	if err := config.SetAuth(token, user.ID, user.Email, user.Username); err != nil {
+		return fmt.Errorf("failed to save authentication: %w", err)
+	}
+
+	utils.PrintSuccess("Successfully logged in as %s with a personal access token", user.Username)
+	return nil
+}
+
+// clientCredentialsLogin logs in a service account with the client
+// credentials grant. Its token is renewed with the client secret when it
+// expires, so daemons keep running without a user's login.
+func clientCredentialsLogin(ctx context.Context, clientID string) error {
+	if clientID == "" {
+		clientID = os.Getenv(config.ClientIDEnv)
+	}
+	if clientID == "" {
+		return fmt.Errorf("--client-id or %s is required", config.ClientIDEnv)
+	}
+
+	secret, fromEnv, err := readClientSecret(clientID)
+	if err != nil {
+		return err
+	}
+
+	oauthConfig, err := getOAuthConfig(ctx)
+	if err != nil {
+		return err
+	}
+	tokenURL := authPkg.IssuerURL(oauthConfig) + "/oauth/token"
+
+	spinner := utils.NewSpinner("Requesting service account token...")
+	spinner.Start()
+	tokenResp, err := authPkg.ClientCredentialsToken(ctx, tokenURL, clientID, secret, oauthConfig.Audience)
 	spinner.Stop()
+	if err != nil {
+		return err
+	}
 
-	// Save authentication
-	if err := config.SetAuth(tokenResp.AccessToken, userInfo.Sub, userInfo.Email, userInfo.Name); err != nil {
+	client := api.NewClient()
+	client.SetHeader("Authorization", "Bearer "+tokenResp.AccessToken)
+
+	user, err := client.Auth().Profile(ctx)
+	if err != nil {
+		return fmt.Errorf("token rejected by the gateway: %w", err)
+	}
+
+	tokens := config.TokenSet{
+		AccessToken:  tokenResp.AccessToken,
+		Expiry:       tokenResp.Expiry(),
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: secret,
+		Audience:     oauthConfig.Audience,
+	}
+	if err := config.SetAuthTokens(tokens, user.ID, user.Email, user.Username); err != nil {
 		return fmt.Errorf("failed to save authentication: %w", err)
 	}
 
-	utils.PrintSuccess("Successfully authenticated as %s (%s)", userInfo.Name, userInfo.Email)
+	utils.PrintSuccess("Successfully logged in service account %s", user.Username)
+	if fromEnv {
+		utils.PrintInfo("The client secret was not saved; keep %s set so the token can be renewed", config.ClientSecretEnv)
+	}
 	return nil
 }
 
-func legacyLogin(username, password string) error {
+// readClientSecret returns the client secret from the environment, from an
+// earlier login of the same client, or from the terminal, and whether it came
+// from the environment
+func readClientSecret(clientID string) (string, bool, error) {
+	if secret := os.Getenv(config.ClientSecretEnv); secret != "" {
+		return secret, true, nil
+	}
+	if secret := config.StoredClientSecret(clientID); secret != "" {
+		return secret, false, nil
+	}
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return "", false, fmt.Errorf("no client secret: set %s or run interactively", config.ClientSecretEnv)
+	}
+
+	fmt.Print("Client secret: ")
+	secretBytes, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Println()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read client secret: %w", err)
+	}
+	secret := strings.TrimSpace(string(secretBytes))
+	if secret == "" {
+		return "", false, fmt.Errorf("client secret is required")
+	}
+	return secret, false, nil
+}
+
+func legacyLogin(ctx context.Context, username, password string) error {
 	client := api.NewClient()
 
 	if username == "" {
@@ -177,8 +374,7 @@ func legacyLogin(username, password string) error {
 		Password: password,
 	}
 
-	var authResp api.AuthResponse
-	err := client.Post("/auth/login", loginReq, &authResp)
+	authResp, err := client.Auth().Login(ctx, loginReq)
 	spinner.Stop()
 
 	if err != nil {
@@ -1,28 +1,98 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
 
-	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	authPkg "github.com/Exmplr-AI/aphelion-cli/pkg/auth"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
 func newLogoutCmd() *cobra.Command {
+	var all bool
+
 	cmd := &cobra.Command{
-		Use:   "logout",
+		Use:   "logout [ACCOUNT]",
 		Short: "Logout from Aphelion Gateway",
-		Long:  "Clear stored authentication credentials",
+		Long: `Clear the stored credentials of an account, by default the current one.
+
+Tokens from an OAuth login are also revoked with the authorization server, so
+they stop working everywhere. Personal access tokens are only removed locally;
+use 'aphelion auth token revoke' to revoke them.`,
+		Args: cobra.MaximumNArgs(1),
+		Example: `  # Logout of the current account
+  aphelion auth logout
+
+  # Logout of another account
+  aphelion auth logout alice
+
+  # Logout of every account of the profile
+  aphelion auth logout --all`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := config.ClearAuth(); err != nil {
-				return fmt.Errorf("failed to clear authentication: %w", err)
+			var names []string
+			switch {
+			case all:
+				if len(args) > 0 {
+					return fmt.Errorf("an account cannot be given together with --all")
+				}
+				for _, account := range config.ListAccounts() {
+					names = append(names, account.Name)
+				}
+			case len(args) > 0:
+				if !config.HasAccount(args[0]) {
+					return fmt.Errorf("account %q does not exist in profile %q", args[0], config.GetProfileName())
+				}
+				names = args
+			case config.HasAccount(config.GetAccountName()):
+				names = []string{config.GetAccountName()}
+			}
+
+			if len(names) == 0 {
+				utils.PrintInfo("Not logged in")
+				return nil
+			}
+
+			current := config.GetAccountName()
+			for _, name := range names {
+				if err := logoutAccount(cmd.Context(), name); err != nil {
+					return err
+				}
 			}
 
-			utils.PrintSuccess("Successfully logged out")
+			if next := config.GetAccountName(); next != current && config.HasAccount(next) {
+				utils.PrintInfo("Current account is now %s", next)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&all, "all", false, "logout of every account of the profile")
+
 	return cmd
-}
\ No newline at end of file
+}
+
+// logoutAccount revokes the refresh token of an account, where it has one,
+// and removes the account. A failed revocation only warns, so an unreachable
+// server does not keep the credentials on disk.
+func logoutAccount(ctx context.Context, name string) error {
+	tokens, err := config.AccountTokens(name)
+	if err != nil {
+		return err
+	}
+
+	if tokens.RefreshToken != "" && tokens.TokenURL != "" {
+		if err := authPkg.RevokeToken(ctx, authPkg.RevocationURL(tokens.TokenURL), tokens.ClientID, tokens.RefreshToken); err != nil {
+			utils.PrintWarning("Could not revoke the tokens of %s on the server: %v", name, err)
+		}
+	}
+
+	if err := config.RemoveAccount(name); err != nil {
+		return fmt.Errorf("failed to clear authentication: %w", err)
+	}
+
+	utils.PrintSuccess("Logged out %s", name)
+	return nil
+}
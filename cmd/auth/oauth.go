@@ -2,10 +2,12 @@ package auth
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	authPkg "github.com/Exmplr-AI/aphelion-cli/pkg/auth"
 )
 
 func newOAuthCmd() *cobra.Command {
@@ -16,40 +18,42 @@ func newOAuthCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client := api.NewClient()
 			
-			var authInfo map[string]interface{}
-			if err := client.Get("/auth/info", &authInfo); err != nil {
+			authInfo, err := client.Auth().Info(cmd.Context())
+			if err != nil {
 				return fmt.Errorf("failed to get Auth0 info: %w", err)
 			}
 
 			fmt.Println("Auth0 OAuth Configuration:")
 			fmt.Println("========================")
 			
-			if domain, ok := authInfo["auth0_domain"].(string); ok {
-				fmt.Printf("Domain: %s\n", domain)
+			if authInfo.Domain != "" {
+				fmt.Printf("Domain: %s\n", authInfo.Domain)
 			}
 			
 			clientID := "UbXxpQBSr9AsqpS2ln2jzmsmamromaFC" // Correct client_id
-			if cid, ok := authInfo["client_id"].(string); ok && cid != "" {
-				clientID = cid
+			if authInfo.ClientID != "" {
+				clientID = authInfo.ClientID
 			}
 			fmt.Printf("Client ID: %s\n", clientID)
 			
-			if audience, ok := authInfo["auth0_audience"].(string); ok {
-				fmt.Printf("Audience: %s\n", audience)
+			if authInfo.Audience != "" {
+				fmt.Printf("Audience: %s\n", authInfo.Audience)
 			}
 			
-			fmt.Printf("Redirect URI: %s\n", "http://localhost:8765/callback")
+			redirectURI := authPkg.CallbackURL(authPkg.DefaultCallbackPort)
+			fmt.Printf("Redirect URI: %s\n", redirectURI)
 
 			fmt.Println("\nTo authenticate with Auth0:")
 			fmt.Println("1. Use 'aphelion auth login' for automatic browser-based authentication")
 			fmt.Println("2. Or use 'aphelion auth login --no-launch-browser' to get the URL manually")
 
-			if authURL, ok := authInfo["auth_url"].(string); ok {
-				fullAuthURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=openid%%20profile%%20email&audience=%s",
-					authURL,
+			if authInfo.AuthURL != "" {
+				fullAuthURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s&audience=%s",
+					authInfo.AuthURL,
 					clientID,
-					"http://localhost:8765/callback",
-					authInfo["auth0_audience"],
+					redirectURI,
+					url.QueryEscape(authPkg.Scopes),
+					authInfo.Audience,
 				)
 				fmt.Printf("\nSample Authorization URL:\n%s\n", fullAuthURL)
 			}
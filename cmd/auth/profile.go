@@ -22,8 +22,8 @@ func newProfileCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			var profile api.User
-			if err := client.Get("/auth/profile", &profile); err != nil {
+			profile, err := client.Auth().Profile(cmd.Context())
+			if err != nil {
 				return fmt.Errorf("failed to get profile: %w", err)
 			}
 
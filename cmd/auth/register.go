@@ -84,8 +84,7 @@ func newRegisterCmd() *cobra.Command {
 				Password: password,
 			}
 
-			var authResp api.AuthResponse
-			err := client.Post("/auth/register", registerReq, &authResp)
+			authResp, err := client.Auth().Register(cmd.Context(), registerReq)
 			spinner.Stop()
 
 			if err != nil {
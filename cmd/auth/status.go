@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	authPkg "github.com/Exmplr-AI/aphelion-cli/pkg/auth"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+// Results of checking the token against the gateway
+const (
+	gatewayAccepted    = "accepted"
+	gatewayRejected    = "rejected"
+	gatewayUnreachable = "unreachable"
+	gatewaySkipped     = "skipped"
+)
+
+func newStatusCmd() *cobra.Command {
+	var offline bool
+
+	cmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"whoami"},
+		Short:   "Show authentication status",
+		Long: `Show whether you are logged in, as whom, and where the credentials in use come
+from. JWT access tokens are decoded locally to show their issuer, audience,
+scopes and expiry, and the token is checked against the gateway.
+
+The exit code tells scripts and shell prompts the result:
+  0  logged in
+  2  not logged in
+  3  the token has expired or was rejected by the gateway`,
+		Args: cobra.NoArgs,
+		Example: `  # Show the login state
+  aphelion auth status
+
+  # Check quickly without contacting the gateway, e.g. in a shell prompt
+  aphelion auth status --offline >/dev/null 2>&1 || echo "not logged in"
+
+  # Machine-readable status
+  aphelion auth whoami -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.GetConfig()
+			format := config.GetOutputFormat()
+
+			status := map[string]interface{}{
+				"profile":       cfg.Profile,
+				"api_url":       cfg.APIUrl,
+				"authenticated": false,
+			}
+
+			if !config.IsAuthenticated() {
+				if format != "table" {
+					utils.PrintOutput(status, format)
+				} else {
+					fmt.Printf("Profile:     %s\n", cfg.Profile)
+					fmt.Printf("API URL:     %s\n", cfg.APIUrl)
+				}
+				return statusExit(cmd, utils.NewExitError(utils.ExitNotAuthenticated,
+					"not logged in. Run 'aphelion auth login' or set %s", config.TokenEnv))
+			}
+
+			// Check the token first: an expired OAuth token is refreshed on
+			// the way, and the details below show the renewed one
+			gateway, gatewayErr := gatewaySkipped, error(nil)
+			if !offline {
+				gateway, gatewayErr = checkGateway(cmd)
+			}
+
+			tokens := config.GetTokenSet()
+			source, detail := config.AccessTokenSource()
+			claims, _ := authPkg.DecodeJWT(tokens.AccessToken)
+
+			expiry := tokens.Expiry
+			if claims != nil && !claims.Expiry().IsZero() {
+				expiry = claims.Expiry()
+			}
+			expired := !expiry.IsZero() && time.Now().After(expiry)
+
+			status["authenticated"] = true
+			status["source"] = string(source)
+			status["source_detail"] = detail
+			status["refreshable"] = tokens.CanRefresh()
+			status["gateway"] = gateway
+			// The stored user belongs to the saved login, not to a token from
+			// the environment
+			if source != config.SourceEnv {
+				status["account"] = cfg.Account
+				status["user"] = cfg.Username
+				status["email"] = cfg.Email
+			}
+			if !expiry.IsZero() {
+				status["expires_at"] = expiry.Format(time.RFC3339)
+				status["expires_in"] = int(time.Until(expiry).Seconds())
+				status["expired"] = expired
+			}
+			if claims != nil {
+				status["issuer"] = claims.Issuer
+				status["subject"] = claims.Subject
+				status["audience"] = []string(claims.Audience)
+				status["scopes"] = claims.Scopes()
+			}
+
+			if format != "table" {
+				if err := utils.PrintOutput(status, format); err != nil {
+					return err
+				}
+			} else {
+				printStatus(cfg, tokens, source, detail, claims, expiry, gateway, gatewayErr)
+			}
+
+			switch {
+			case gateway == gatewayRejected:
+				return statusExit(cmd, utils.NewExitError(utils.ExitTokenInvalid,
+					"the gateway rejected the token. Run 'aphelion auth login' to sign in again"))
+			case expired && !tokens.CanRefresh():
+				return statusExit(cmd, utils.NewExitError(utils.ExitTokenInvalid,
+					"the token has expired. Run 'aphelion auth login' to sign in again"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&offline, "offline", false, "only inspect the stored token, do not contact the gateway")
+
+	return cmd
+}
+
+// checkGateway asks the gateway whether it accepts the token
+func checkGateway(cmd *cobra.Command) (string, error) {
+	client := api.NewClient()
+	_, err := client.Auth().Profile(cmd.Context())
+	if err == nil {
+		return gatewayAccepted, nil
+	}
+
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return gatewayRejected, err
+	}
+	return gatewayUnreachable, err
+}
+
+func printStatus(cfg *config.Config, tokens config.TokenSet, source config.Source, detail string, claims *authPkg.Claims, expiry time.Time, gateway string, gatewayErr error) {
+	fmt.Printf("Profile:     %s\n", cfg.Profile)
+	fmt.Printf("API URL:     %s\n", cfg.APIUrl)
+
+	if source == config.SourceEnv {
+		fmt.Printf("User:        (token from environment)\n")
+	} else {
+		fmt.Printf("Account:     %s\n", cfg.Account)
+		fmt.Printf("User:        %s (%s)\n", cfg.Username, cfg.Email)
+	}
+	fmt.Printf("Credentials: %s\n", describeSource(source, detail))
+
+	if claims != nil {
+		fmt.Printf("Issuer:      %s\n", claims.Issuer)
+		if len(claims.Audience) > 0 {
+			fmt.Printf("Audience:    %s\n", strings.Join(claims.Audience, ", "))
+		}
+		if scopes := claims.Scopes(); len(scopes) > 0 {
+			fmt.Printf("Scopes:      %s\n", strings.Join(scopes, " "))
+		}
+	} else {
+		fmt.Printf("Token:       opaque (not a JWT)\n")
+	}
+
+	if !expiry.IsZero() {
+		fmt.Printf("Expires:     %s (%s)\n", expiry.Local().Format("2006-01-02 15:04:05"), countdown(expiry))
+	}
+	if tokens.CanRefresh() {
+		fmt.Printf("Refresh:     renewed automatically\n")
+	}
+
+	switch gateway {
+	case gatewayAccepted:
+		utils.PrintSuccess("Token accepted by the gateway")
+	case gatewayRejected:
+		utils.PrintError("Token rejected by the gateway: %v", gatewayErr)
+	case gatewayUnreachable:
+		utils.PrintWarning("Could not check the token with the gateway: %v", gatewayErr)
+	}
+}
+
+// countdown describes how long until t, or how long ago it passed
+func countdown(t time.Time) string {
+	d := time.Until(t).Round(time.Second)
+	if d < 0 {
+		return fmt.Sprintf("expired %s ago", -d)
+	}
+	if d > time.Hour {
+		d = d.Round(time.Minute)
+	}
+	return fmt.Sprintf("in %s", d)
+}
+
+// statusExit returns err without the usage text, which would only hide the
+// status printed above
+func statusExit(cmd *cobra.Command, err *utils.ExitError) error {
+	cmd.SilenceUsage = true
+	return err
+}
+
+// describeSource explains where the access token in use comes from
+func describeSource(source config.Source, detail string) string {
+	switch source {
+	case config.SourceEnv:
+		return fmt.Sprintf("environment variable %s", detail)
+	case config.SourceStore:
+		return fmt.Sprintf("%s credential store", detail)
+	case config.SourceProfile, config.SourceFile:
+		return fmt.Sprintf("config file %s", detail)
+	}
+	return string(source)
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage personal access tokens",
+		Long: `Create, list and revoke personal access tokens.
+
+Personal access tokens are long-lived credentials for CI pipelines and other
+non-interactive use. Pass one in the APHELION_TOKEN environment variable, or
+save it with 'aphelion auth login --with-token'.`,
+	}
+
+	cmd.AddCommand(newTokenCreateCmd())
+	cmd.AddCommand(newTokenListCmd())
+	cmd.AddCommand(newTokenRevokeCmd())
+
+	return cmd
+}
+
+func newTokenCreateCmd() *cobra.Command {
+	var name, expiresIn string
+	var scopes []string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a personal access token",
+		Long:  "Create a personal access token. The token is only shown once, so store it safely.",
+		Args:  cobra.NoArgs,
+		Example: `  # Create a token for CI that expires in 90 days
+  aphelion auth token create --name ci --expires-in 90d
+
+  # Create a token limited to reading the registry and memory
+  aphelion auth token create --name reader --scope registry:read --scope memory:read
+
+  # Use it in a pipeline
+  export APHELION_TOKEN=<token>`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !config.IsAuthenticated() {
+				return fmt.Errorf("authentication required. Please run 'aphelion auth login' first")
+			}
+			if name == "" {
+				return fmt.Errorf("--name is required")
+			}
+
+			req := api.CreateTokenRequest{Name: name, Scopes: scopes}
+			if expiresIn != "" {
+				lifetime, err := parseLifetime(expiresIn)
+				if err != nil {
+					return err
+				}
+				req.ExpiresIn = int(lifetime / time.Second)
+			}
+
+			client := api.NewClient()
+			token, err := client.Auth().CreateToken(cmd.Context(), req)
+			if err != nil {
+				return fmt.Errorf("failed to create token: %w", err)
+			}
+
+			if format := config.GetOutputFormat(); format != "table" {
+				return utils.PrintOutput(token, format)
+			}
+
+			utils.PrintSuccess("Created token %s (%s)", token.Name, token.ID)
+			fmt.Printf("\n%s\n\n", token.Token)
+			utils.PrintWarning("Copy the token now, it will not be shown again")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "name describing what the token is for (required)")
+	cmd.Flags().StringArrayVar(&scopes, "scope", nil, "scope granted to the token (repeatable, default all scopes of your account)")
+	cmd.Flags().StringVar(&expiresIn, "expires-in", "", "token lifetime, e.g. 720h or 90d (default never expires)")
+
+	return cmd
+}
+
+func newTokenListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List personal access tokens",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !config.IsAuthenticated() {
+				return fmt.Errorf("authentication required. Please run 'aphelion auth login' first")
+			}
+
+			client := api.NewClient()
+			tokens, err := client.Auth().ListTokens(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list tokens: %w", err)
+			}
+
+			printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), "ID", "Name", "Scopes", "Created", "Expires", "Last Used")
+			if err != nil {
+				return err
+			}
+			printer.Empty("No personal access tokens found")
+
+			for _, token := range tokens {
+				scopes := strings.Join(token.Scopes, ",")
+				if scopes == "" {
+					scopes = "all"
+				}
+
+				if err := printer.Print(map[string]interface{}{
+					"ID":        token.ID,
+					"Name":      token.Name,
+					"Scopes":    scopes,
+					"Created":   token.CreatedAt.Format("2006-01-02"),
+					"Expires":   formatTokenTime(token.ExpiresAt, "never"),
+					"Last Used": formatTokenTime(token.LastUsedAt, "never"),
+				}); err != nil {
+					return err
+				}
+			}
+
+			return printer.Close()
+		},
+	}
+
+	return cmd
+}
+
+func newTokenRevokeCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "revoke [TOKEN_ID]...",
+		Short: "Revoke personal access tokens",
+		Args:  cobra.MinimumNArgs(1),
+		Example: `  # Revoke a token (with confirmation)
+  aphelion auth token revoke tok-12
+
+  # Revoke without confirmation
+  aphelion auth token revoke tok-12 tok-13 --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !config.IsAuthenticated() {
+				return fmt.Errorf("authentication required. Please run 'aphelion auth login' first")
+			}
+
+			if !force {
+				fmt.Printf("Are you sure you want to revoke %s? (y/N): ", strings.Join(args, ", "))
+				var response string
+				if _, err := fmt.Scanln(&response); err != nil || (response != "y" && response != "Y") {
+					utils.PrintInfo("Operation cancelled")
+					return nil
+				}
+			}
+
+			client := api.NewClient()
+			for _, id := range args {
+				if err := client.Auth().RevokeToken(cmd.Context(), id); err != nil {
+					return fmt.Errorf("failed to revoke token %s: %w", id, err)
+				}
+				utils.PrintSuccess("Revoked token %s", id)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "skip confirmation prompt")
+
+	return cmd
+}
+
+// parseLifetime parses a duration, also accepting whole days such as 90d
+func parseLifetime(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid lifetime %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	lifetime, err := time.ParseDuration(value)
+	if err != nil || lifetime <= 0 {
+		return 0, fmt.Errorf("invalid lifetime %q: use a duration such as 720h or a number of days such as 90d", value)
+	}
+	return lifetime, nil
+}
+
+func formatTokenTime(t *time.Time, fallback string) string {
+	if t == nil || t.IsZero() {
+		return fallback
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Configuration commands",
+		Long:  "Manage CLI configuration and named profiles (contexts)",
+	}
+
+	cmd.AddCommand(newViewCmd())
+	cmd.AddCommand(newGetCmd())
+	cmd.AddCommand(newSetCmd())
+	cmd.AddCommand(newUnsetCmd())
+	cmd.AddCommand(newPathCmd())
+	cmd.AddCommand(newGetContextsCmd())
+	cmd.AddCommand(newCurrentContextCmd())
+	cmd.AddCommand(newUseContextCmd())
+	cmd.AddCommand(newSetContextCmd())
+	cmd.AddCommand(newDeleteContextCmd())
+
+	return cmd
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	configPkg "github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newGetContextsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "get-contexts",
+		Aliases: []string{"get-profiles"},
+		Short:   "List configuration profiles",
+		Long:    "List all named profiles in the config file. The current context is marked with *",
+		Example: `  # List profiles
+  aphelion config get-contexts`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := configPkg.ListProfiles()
+			printer, err := utils.NewStreamPrinter(configPkg.GetOutputFormat(), "Current", "Name", "API URL", "User")
+			if err != nil {
+				return err
+			}
+			printer.Empty("No profiles configured. Run 'aphelion auth login' or 'aphelion config set-context' to create one")
+
+			active := configPkg.GetProfileName()
+			for _, name := range names {
+				profile, _ := configPkg.GetProfile(name)
+
+				current := ""
+				if name == active {
+					current = "*"
+				}
+
+				user := ""
+				if account := profile.Current(); account != nil {
+					user = account.Username
+				}
+
+				if err := printer.Print(map[string]interface{}{
+					"Current": current,
+					"Name":    name,
+					"API URL": profile.APIUrl,
+					"User":    user,
+				}); err != nil {
+					return err
+				}
+			}
+
+			return printer.Close()
+		},
+	}
+
+	return cmd
+}
+
+func newCurrentContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "current-context",
+		Short: "Show the active profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println(configPkg.GetProfileName())
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newUseContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "use-context <name>",
+		Aliases: []string{"use-profile"},
+		Short:   "Switch the current profile",
+		Long:    "Make the named profile the default for subsequent commands",
+		Args:    cobra.ExactArgs(1),
+		Example: `  # Switch to the staging profile
+  aphelion config use-context staging`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := configPkg.UseProfile(args[0]); err != nil {
+				return fmt.Errorf("failed to switch profile: %w", err)
+			}
+
+			utils.PrintSuccess("Switched to profile %s", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newSetContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-context <name>",
+		Short: "Create or update a profile",
+		Long: `Create a named profile, or update an existing one. The global --api-url
+and --output flags set the profile's API URL and default output format.`,
+		Args: cobra.ExactArgs(1),
+		Example: `  # Add a profile for a local gateway
+  aphelion config set-context local --api-url http://localhost:8080
+
+  # Log in to it
+  aphelion auth login --profile local`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var settings configPkg.Profile
+
+			if cmd.Flags().Changed("api-url") {
+				settings.APIUrl, _ = cmd.Flags().GetString("api-url")
+			}
+			if cmd.Flags().Changed("output") {
+				settings.Output, _ = cmd.Flags().GetString("output")
+				if settings.Output != "json" && settings.Output != "yaml" && settings.Output != "table" {
+					return fmt.Errorf("unsupported output format: %s", settings.Output)
+				}
+			}
+
+			if err := configPkg.SetProfile(args[0], settings); err != nil {
+				return fmt.Errorf("failed to save profile: %w", err)
+			}
+
+			utils.PrintSuccess("Profile %s saved", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newDeleteContextCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete-context <name>",
+		Short: "Delete a profile",
+		Long:  "Delete a named profile and the credentials stored with it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if !force {
+				fmt.Printf("Are you sure you want to delete profile %s? (y/N): ", name)
+				var response string
+				if _, err := fmt.Scanln(&response); err != nil || (response != "y" && response != "Y") {
+					utils.PrintInfo("Operation cancelled")
+					return nil
+				}
+			}
+
+			if err := configPkg.DeleteProfile(name); err != nil {
+				return fmt.Errorf("failed to delete profile: %w", err)
+			}
+
+			utils.PrintSuccess("Profile %s deleted", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "skip confirmation prompt")
+
+	return cmd
+}
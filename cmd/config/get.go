@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	configPkg "github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newGetCmd() *cobra.Command {
+	var reveal, showSource bool
+
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a setting",
+		Long:  "Print the effective value of a setting for the active profile. Secrets are redacted unless --reveal is given.\n\n" + keyHelp(),
+		Args:  cobra.ExactArgs(1),
+		Example: `  # Print the API URL in use
+  aphelion config get api_url
+
+  # Show where the timeout comes from
+  aphelion config get timeout --show-source`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := configPkg.LookupKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			value, source, err := configPkg.Value(key.Name)
+			if err != nil {
+				return err
+			}
+
+			value = displayValue(key, value, reveal)
+			if showSource {
+				fmt.Printf("%s\t(%s)\n", value, source)
+				return nil
+			}
+
+			fmt.Println(value)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "print secrets in full")
+	cmd.Flags().BoolVar(&showSource, "show-source", false, "also print where the value came from")
+
+	return cmd
+}
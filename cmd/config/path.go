@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	configPkg "github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newPathCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file path",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configPkg.ConfigFilePath()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(path)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	configPkg "github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newSetCmd() *cobra.Command {
+	var global bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value in the config file",
+		Long: `Set a value in the config file. Profile settings are written to the active
+profile unless --global is given, in which case they apply to every profile
+that does not set them itself.
+
+` + keyHelp(),
+		Args: cobra.ExactArgs(2),
+		Example: `  # Use JSON output for the current profile
+  aphelion config set output json
+
+  # Raise the request timeout for every profile
+  aphelion config set timeout 1m`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := configPkg.LookupKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := configPkg.SetValue(key.Name, args[1], global); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key.Name, err)
+			}
+
+			utils.PrintSuccess("Set %s to %s %s", key.Name, args[1], target(key, global))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&global, "global", false, "write profile settings to the top level of the config file")
+
+	return cmd
+}
+
+func newUnsetCmd() *cobra.Command {
+	var global bool
+
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a value from the config file",
+		Long:  "Remove a value from the active profile, or from the top level of the config file with --global.\n\n" + keyHelp(),
+		Args:  cobra.ExactArgs(1),
+		Example: `  # Go back to the default output format
+  aphelion config unset output`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := configPkg.LookupKey(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := configPkg.UnsetValue(key.Name, global); err != nil {
+				return fmt.Errorf("failed to unset %s: %w", key.Name, err)
+			}
+
+			utils.PrintSuccess("Unset %s %s", key.Name, target(key, global))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&global, "global", false, "remove the top-level value instead of the profile's")
+
+	return cmd
+}
+
+// target describes where SetValue and UnsetValue write a key
+func target(key configPkg.Key, global bool) string {
+	if key.Scope == configPkg.ScopeProfile && !global {
+		return fmt.Sprintf("in profile %s", configPkg.GetProfileName())
+	}
+	return "for all profiles"
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+	configPkg "github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+func newViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the effective configuration",
+		Long: `Show every setting for the active profile along with where its value came
+from. Secrets are redacted.
+
+Settings are resolved in this order, first match wins:
+  flag > env > profile > file > default`,
+		Args: cobra.NoArgs,
+		Example: `  # Show the effective configuration
+  aphelion config view
+
+  # Show the configuration of another profile
+  aphelion config view --profile staging`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := configPkg.GetOutputFormat()
+
+			if format != "table" {
+				settings := map[string]interface{}{}
+				for _, key := range configPkg.Keys() {
+					value, _, err := configPkg.Value(key.Name)
+					if err != nil {
+						return err
+					}
+					settings[key.Name] = displayValue(key, value, false)
+				}
+				return utils.PrintOutput(settings, format)
+			}
+
+			path, err := configPkg.ConfigFilePath()
+			if err != nil {
+				return err
+			}
+			utils.PrintInfo("Profile %s, config file %s", configPkg.GetProfileName(), path)
+
+			printer, err := utils.NewStreamPrinter(format, "Key", "Value", "Source")
+			if err != nil {
+				return err
+			}
+			defer printer.Close()
+
+			for _, key := range configPkg.Keys() {
+				value, source, err := configPkg.Value(key.Name)
+				if err != nil {
+					return err
+				}
+
+				if err := printer.Print(map[string]interface{}{
+					"Key":    key.Name,
+					"Value":  displayValue(key, value, false),
+					"Source": string(source),
+				}); err != nil {
+					return err
+				}
+			}
+
+			return printer.Close()
+		},
+	}
+
+	return cmd
+}
+
+// displayValue redacts secrets unless reveal is set
+func displayValue(key configPkg.Key, value string, reveal bool) string {
+	if key.Secret && !reveal {
+		return configPkg.Redact(value)
+	}
+	return value
+}
+
+// keyHelp lists the known settings for command help text
+func keyHelp() string {
+	help := "Available keys:\n"
+	for _, key := range configPkg.Keys() {
+		help += fmt.Sprintf("  %-22s %s (%s)\n", key.Name, key.Description, key.Scope)
+	}
+	return help
+}
@@ -0,0 +1,17 @@
+package dev
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewDevCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Local development tools",
+		Long:  "Tools for developing agents and testing the CLI without the hosted Aphelion Gateway",
+	}
+
+	cmd.AddCommand(newMockGatewayCmd())
+
+	return cmd
+}
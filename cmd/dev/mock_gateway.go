@@ -0,0 +1,105 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/mockgateway"
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
+)
+
+func newMockGatewayCmd() *cobra.Command {
+	var (
+		host        string
+		port        int
+		fixturesDir string
+		storeFile   string
+		quiet       bool
+		tokenTTL    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mock-gateway",
+		Short: "Start a local mock Aphelion Gateway",
+		Long: `Start an in-process HTTP server implementing the gateway endpoints used by the CLI
+(auth, services, tools, memory and analytics), backed by an in-memory store.
+
+The store can be seeded from a fixtures directory containing any of services.json,
+manifests.json, tools.json, memories.json and users.json. Use --store to persist
+changes to a JSON file between runs. Use --token-ttl to issue OAuth access tokens
+that expire, to exercise token refresh.`,
+		Example: `  # Start the mock gateway on port 8080
+  aphelion dev mock-gateway --port 8080
+
+  # Seed from fixtures and persist changes
+  aphelion dev mock-gateway --fixtures ./fixtures --store ./mock-store.json
+
+  # Point the CLI at the mock gateway
+  APHELION_API_URL=http://localhost:8080 aphelion registry list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := mockgateway.NewStore(fixturesDir, storeFile)
+			if err != nil {
+				return err
+			}
+
+			var logger io.Writer
+			if !quiet {
+				logger = os.Stdout
+			}
+
+			listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s:%d: %w", host, port, err)
+			}
+
+			gateway := mockgateway.NewServer(store, logger)
+			gateway.SetTokenTTL(tokenTTL)
+
+			server := &http.Server{
+				Handler:           gateway,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			url := fmt.Sprintf("http://%s", listener.Addr())
+			utils.PrintSuccess("Mock gateway listening on %s", url)
+			utils.PrintInfo("Use it with: APHELION_API_URL=%s aphelion <command>", url)
+			utils.PrintInfo("Press Ctrl+C to stop.")
+
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- server.Serve(listener)
+			}()
+
+			select {
+			case err := <-errChan:
+				if !errors.Is(err, http.ErrServerClosed) {
+					return fmt.Errorf("mock gateway failed: %w", err)
+				}
+				return nil
+			case <-cmd.Context().Done():
+			}
+
+			fmt.Println("\n🛑 Stopping mock gateway...")
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "address to bind")
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "port to listen on (0 picks a free port)")
+	cmd.Flags().StringVar(&fixturesDir, "fixtures", "", "directory with JSON fixtures to seed the store")
+	cmd.Flags().StringVar(&storeFile, "store", "", "JSON file to load and persist the store")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not log requests")
+	cmd.Flags().DurationVar(&tokenTTL, "token-ttl", 0, "lifetime of issued OAuth access tokens (default 1h)")
+
+	return cmd
+}
@@ -34,18 +34,15 @@ func newClearCmd() *cobra.Command {
 			}
 
 			var warningMsg, spinnerMsg, successMsg string
-			var endpoint string
 			
 			if sessionID != "" {
 				warningMsg = fmt.Sprintf("This will delete all memories for session %s permanently!", sessionID)
 				spinnerMsg = fmt.Sprintf("Deleting memories for session %s...", sessionID)
 				successMsg = fmt.Sprintf("Memories for session %s have been deleted", sessionID)
-				endpoint = fmt.Sprintf("/memory?session_id=%s", sessionID)
 			} else {
 				warningMsg = "This will delete ALL of your memories permanently!"
 				spinnerMsg = "Deleting all memories..."
 				successMsg = "All memories have been deleted"
-				endpoint = "/memory"
 			}
 
 			if !force {
@@ -63,7 +60,7 @@ func newClearCmd() *cobra.Command {
 			spinner := utils.NewSpinner(spinnerMsg)
 			spinner.Start()
 
-			err := client.Delete(endpoint)
+			err := client.Memory().Clear(cmd.Context(), sessionID)
 			spinner.Stop()
 
 			if err != nil {
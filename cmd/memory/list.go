@@ -1,8 +1,8 @@
 package memory
 
 import (
+	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -15,6 +15,8 @@ func newListCmd() *cobra.Command {
 	var limit int
 	var sort string
 	var dateFrom, dateTo string
+	var all bool
+	var pageSize, maxItems int
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -27,57 +29,75 @@ func newListCmd() *cobra.Command {
   aphelion memory list --limit 10 --sort oldest
 
   # List memories from specific date range
-  aphelion memory list --date-from 2023-01-01 --date-to 2023-12-31`,
+  aphelion memory list --date-from 2023-01-01 --date-to 2023-12-31
+
+  # Stream every memory, following pagination
+  aphelion memory list --all --output json
+
+  # Stream at most 500 memories, 100 per request
+  aphelion memory list --max-items 500 --page-size 100`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !config.IsAuthenticated() {
 				return fmt.Errorf("authentication required. Please run 'aphelion auth login' first")
 			}
+			if pageSize < 1 || pageSize > 100 {
+				return fmt.Errorf("--page-size must be between 1 and 100")
+			}
+			if maxItems < 0 {
+				return fmt.Errorf("--max-items must not be negative")
+			}
 
 			client := api.NewClient()
 			
+			if all || maxItems > 0 {
+				pager := client.Memory().ListPager(api.MemoryListOptions{
+					Limit:    pageSize,
+					Sort:     sort,
+					DateFrom: dateFrom,
+					DateTo:   dateTo,
+				}).SetMaxItems(maxItems)
+				return streamMemories(cmd.Context(), pager)
+			}
+
 			// Try paginated endpoint first, fallback to basic endpoint
 			var memories []api.Memory
 			
 			if limit != 10 || sort != "newest" || dateFrom != "" || dateTo != "" {
 				// Use paginated endpoint when filters are specified
-				params := map[string]string{
-					"limit": strconv.Itoa(limit),
-					"sort":  sort,
-				}
-
-				if dateFrom != "" {
-					params["date_from"] = dateFrom
-				}
-				if dateTo != "" {
-					params["date_to"] = dateTo
-				}
-
-				var response api.MemoriesResponse
-				if err := client.GetWithQuery("/memory/paginated", params, &response); err != nil {
+				response, err := client.Memory().ListPage(cmd.Context(), api.MemoryListOptions{
+					Limit:    limit,
+					Sort:     sort,
+					DateFrom: dateFrom,
+					DateTo:   dateTo,
+				})
+				if err != nil {
 					// Fallback to basic endpoint
-					if err := client.Get("/memory", &memories); err != nil {
+					response, err = client.Memory().List(cmd.Context())
+					if err != nil {
 						return fmt.Errorf("failed to list memories: %w", err)
 					}
-				} else {
-					memories = response.Memories
 				}
+				memories = response.Memories
 			} else {
 				// Use basic endpoint for simple list
-				var response api.MemoriesResponse
-				if err := client.Get("/memory", &response); err != nil {
+				response, err := client.Memory().List(cmd.Context())
+				if err != nil {
 					return fmt.Errorf("failed to list memories: %w", err)
 				}
 				memories = response.Memories
 			}
 
-			if len(memories) == 0 {
-				utils.PrintInfo("No memories found")
-				return nil
+			// Messages would break JSON and YAML output read by scripts
+			format := config.GetOutputFormat()
+			if format == "table" {
+				if len(memories) == 0 {
+					utils.PrintInfo("No memories found")
+					return nil
+				}
+				utils.PrintInfo("Found %d memories", len(memories))
 			}
 
-			utils.PrintInfo("Found %d memories", len(memories))
-			
-			var data []map[string]interface{}
+			data := []map[string]interface{}{}
 			for _, memory := range memories {
 				data = append(data, map[string]interface{}{
 					"ID":         memory.ID,
@@ -87,7 +107,7 @@ func newListCmd() *cobra.Command {
 				})
 			}
 
-			return utils.PrintOutput(data, config.GetOutputFormat())
+			return utils.PrintOutput(data, format)
 		},
 	}
 
@@ -95,6 +115,42 @@ func newListCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&sort, "sort", "s", "newest", "sort order (newest, oldest)")
 	cmd.Flags().StringVar(&dateFrom, "date-from", "", "filter from date (YYYY-MM-DD)")
 	cmd.Flags().StringVar(&dateTo, "date-to", "", "filter to date (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&all, "all", false, "fetch every page of results")
+	cmd.Flags().IntVar(&pageSize, "page-size", 50, "number of memories to request per page with --all or --max-items (1-100)")
+	cmd.Flags().IntVar(&maxItems, "max-items", 0, "stop after this many memories, following pages as needed (0 = no limit)")
 
 	return cmd
+}
+
+// streamMemories prints memories as pages arrive
+func streamMemories(ctx context.Context, pager *api.Pager[api.Memory]) error {
+	printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), "ID", "Session ID", "Summary", "Created")
+	if err != nil {
+		return err
+	}
+
+	for pager.Next(ctx) {
+		memory := pager.Item()
+		if err := printer.Print(map[string]interface{}{
+			"ID":         memory.ID,
+			"Session ID": memory.SessionID,
+			"Summary":    memory.Summary,
+			"Created":    memory.CreatedAt.Format("2006-01-02 15:04:05"),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// A failed listing is not an empty one
+	err = pager.Err()
+	if err == nil {
+		printer.Empty("No memories found")
+	}
+	if closeErr := printer.Close(); closeErr != nil {
+		return closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list memories: %w", err)
+	}
+	return nil
 }
\ No newline at end of file
@@ -2,7 +2,6 @@ package memory
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -33,14 +32,12 @@ func newSearchCmd() *cobra.Command {
 			query := args[0]
 			client := api.NewClient()
 			
-			params := map[string]string{
-				"q":         query,
-				"limit":     strconv.Itoa(limit),
-				"threshold": fmt.Sprintf("%.2f", threshold),
-			}
-
-			var response []api.Memory
-			if err := client.GetWithQuery("/memory/search", params, &response); err != nil {
+			response, err := client.Memory().Search(cmd.Context(), api.MemorySearchOptions{
+				Query:     query,
+				Limit:     limit,
+				Threshold: threshold,
+			})
+			if err != nil {
 				return fmt.Errorf("failed to search memories: %w", err)
 			}
 
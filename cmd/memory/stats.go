@@ -27,8 +27,8 @@ func newStatsCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			var stats api.MemoryStats
-			if err := client.Get("/memory/stats", &stats); err != nil {
+			stats, err := client.Memory().Stats(cmd.Context())
+			if err != nil {
 				return fmt.Errorf("failed to get memory statistics: %w", err)
 			}
 
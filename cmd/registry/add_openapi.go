@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -31,22 +32,6 @@ type OpenAPIServer struct {
 	Description string `json:"description,omitempty"`
 }
 
-type STELLAManifest struct {
-	Name        string                   `json:"name"`
-	Description string                   `json:"description"`
-	Version     string                   `json:"version"`
-	Tools       []STELLATool             `json:"tools"`
-	Metadata    map[string]interface{}   `json:"metadata"`
-}
-
-type STELLATool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Method      string                 `json:"method"`
-	Endpoint    string                 `json:"endpoint"`
-	Parameters  map[string]interface{} `json:"parameters"`
-}
-
 func newAddOpenAPICmd() *cobra.Command {
 	var (
 		file     string
@@ -68,7 +53,7 @@ func newAddOpenAPICmd() *cobra.Command {
 				return fmt.Errorf("OpenAPI file is required")
 			}
 
-			return processOpenAPIFile(file, name, desc, baseURL)
+			return processOpenAPIFile(cmd.Context(), file, name, desc, baseURL)
 		},
 	}
 
@@ -82,7 +67,7 @@ func newAddOpenAPICmd() *cobra.Command {
 	return cmd
 }
 
-func processOpenAPIFile(file, name, desc, baseURL string) error {
+func processOpenAPIFile(ctx context.Context, file, name, desc, baseURL string) error {
 	// Read OpenAPI file
 	specData, err := os.ReadFile(file)
 	if err != nil {
@@ -117,15 +102,15 @@ func processOpenAPIFile(file, name, desc, baseURL string) error {
 	}
 
 	// Register service with STELLA manifest
-	return registerServiceWithSTELLA(stella)
+	return registerServiceWithSTELLA(ctx, stella)
 }
 
-func generateSTELLAManifest(spec OpenAPISpec) STELLAManifest {
-	stella := STELLAManifest{
+func generateSTELLAManifest(spec OpenAPISpec) api.STELLAManifest {
+	stella := api.STELLAManifest{
 		Name:        spec.Info.Title,
 		Description: spec.Info.Description,
 		Version:     spec.Info.Version,
-		Tools:       []STELLATool{},
+		Tools:       []api.STELLATool{},
 		Metadata: map[string]interface{}{
 			"openapi_version": spec.OpenAPI,
 			"generated_by":    "aphelion-cli",
@@ -149,7 +134,7 @@ func generateSTELLAManifest(spec OpenAPISpec) STELLAManifest {
 	return stella
 }
 
-func convertOperationToTool(method, path string, operation map[string]interface{}) *STELLATool {
+func convertOperationToTool(method, path string, operation map[string]interface{}) *api.STELLATool {
 	// Skip non-HTTP methods
 	validMethods := map[string]bool{
 		"get": true, "post": true, "put": true, "delete": true, 
@@ -160,7 +145,7 @@ func convertOperationToTool(method, path string, operation map[string]interface{
 		return nil
 	}
 
-	tool := &STELLATool{
+	tool := &api.STELLATool{
 		Method:     method,
 		Endpoint:   path,
 		Parameters: make(map[string]interface{}),
@@ -225,14 +210,13 @@ func convertOperationToTool(method, path string, operation map[string]interface{
 	return tool
 }
 
-func registerServiceWithSTELLA(stella STELLAManifest) error {
+func registerServiceWithSTELLA(ctx context.Context, stella api.STELLAManifest) error {
 	client := api.NewClient()
 	
 	spinner := utils.NewSpinner("Registering service with STELLA manifest...")
 	spinner.Start()
 
-	var response map[string]interface{}
-	err := client.Post("/owner/services", stella, &response)
+	service, err := client.Services().Register(ctx, stella)
 	spinner.Stop()
 
 	if err != nil {
@@ -241,9 +225,10 @@ func registerServiceWithSTELLA(stella STELLAManifest) error {
 
 	utils.PrintSuccess("Service registered successfully from OpenAPI specification")
 	
-	if service, ok := response["service"].(map[string]interface{}); ok {
-		return utils.OutputTable([]map[string]interface{}{service})
-	}
-
-	return utils.OutputJSON(response)
+	return utils.OutputTable([]map[string]interface{}{{
+		"ID":          service.ID,
+		"Name":        service.Name,
+		"Description": service.Description,
+		"Created":     service.CreatedAt.Format("2006-01-02 15:04:05"),
+	}})
 }
\ No newline at end of file
@@ -39,9 +39,9 @@ func newCreateCmd() *cobra.Command {
 
 			client := api.NewClient()
 			
-			createReq := map[string]interface{}{
-				"name":        name,
-				"description": description,
+			createReq := api.CreateServiceRequest{
+				Name:        name,
+				Description: description,
 			}
 
 			if specFile != "" {
@@ -55,14 +55,13 @@ func newCreateCmd() *cobra.Command {
 					return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
 				}
 
-				createReq["spec"] = spec
+				createReq.Spec = spec
 			}
 
 			spinner := utils.NewSpinner("Registering service...")
 			spinner.Start()
 
-			var response map[string]interface{}
-			err := client.Post("/owner/services", createReq, &response)
+			service, err := client.Services().Create(cmd.Context(), createReq)
 			spinner.Stop()
 
 			if err != nil {
@@ -71,11 +70,16 @@ func newCreateCmd() *cobra.Command {
 
 			utils.PrintSuccess("Service registered successfully")
 			
-			if service, ok := response["service"].(map[string]interface{}); ok {
+			if config.GetOutputFormat() == "json" || config.GetOutputFormat() == "yaml" {
 				return utils.PrintOutput(service, config.GetOutputFormat())
 			}
 
-			return utils.PrintOutput(response, config.GetOutputFormat())
+			return utils.PrintOutput(map[string]interface{}{
+				"ID":          service.ID,
+				"Name":        service.Name,
+				"Description": service.Description,
+				"Created":     service.CreatedAt.Format("2006-01-02 15:04:05"),
+			}, config.GetOutputFormat())
 		},
 	}
 
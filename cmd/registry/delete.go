@@ -44,8 +44,7 @@ func newDeleteCmd() *cobra.Command {
 			spinner := utils.NewSpinner("Deleting service...")
 			spinner.Start()
 
-			endpoint := fmt.Sprintf("/owner/services/%s", serviceID)
-			err := client.Delete(endpoint)
+			err := client.Services().Delete(cmd.Context(), serviceID)
 			spinner.Stop()
 
 			if err != nil {
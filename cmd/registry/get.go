@@ -28,18 +28,16 @@ func newGetCmd() *cobra.Command {
 			client := api.NewClient()
 
 			if showManifest {
-				var manifest map[string]interface{}
-				endpoint := fmt.Sprintf("/services/%s/manifest", serviceID)
-				if err := client.Get(endpoint, &manifest); err != nil {
+				manifest, err := client.Services().Manifest(cmd.Context(), serviceID)
+				if err != nil {
 					return fmt.Errorf("failed to get service manifest: %w", err)
 				}
 
 				return utils.PrintOutput(manifest, config.GetOutputFormat())
 			}
 
-			var service api.Service
-			endpoint := fmt.Sprintf("/services/%s", serviceID)
-			if err := client.Get(endpoint, &service); err != nil {
+			service, err := client.Services().Get(cmd.Context(), serviceID)
+			if err != nil {
 				return fmt.Errorf("failed to get service: %w", err)
 			}
 
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -11,6 +12,9 @@ import (
 )
 
 func newListCmd() *cobra.Command {
+	var all bool
+	var pageSize, maxItems int
+
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List public services",
@@ -19,23 +23,38 @@ func newListCmd() *cobra.Command {
   aphelion registry list
 
   # List services in JSON format
-  aphelion registry list --output json`,
+  aphelion registry list --output json
+
+  # Stream every page of services
+  aphelion registry list --all`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkPagination(pageSize, maxItems); err != nil {
+				return err
+			}
+
 			client := api.NewClient()
 			
-			var response api.ServicesResponse
-			if err := client.Get("/services", &response); err != nil {
+			if all || maxItems > 0 {
+				pager := client.Services().ListPager(pageSize).SetMaxItems(maxItems)
+				return streamServices(cmd.Context(), pager, "No public services found", "ID", "Name", "Description", "Created")
+			}
+
+			response, err := client.Services().List(cmd.Context())
+			if err != nil {
 				return fmt.Errorf("failed to list services: %w", err)
 			}
 
-			if len(response.Services) == 0 {
-				utils.PrintInfo("No public services found")
-				return nil
+			// Messages would break JSON and YAML output read by scripts
+			format := config.GetOutputFormat()
+			if format == "table" {
+				if len(response.Services) == 0 {
+					utils.PrintInfo("No public services found")
+					return nil
+				}
+				utils.PrintInfo("Found %d public services", len(response.Services))
 			}
 
-			utils.PrintInfo("Found %d public services", len(response.Services))
-			
-			var data []map[string]interface{}
+			data := []map[string]interface{}{}
 			for _, service := range response.Services {
 				data = append(data, map[string]interface{}{
 					"ID":          service.ID,
@@ -45,9 +64,69 @@ func newListCmd() *cobra.Command {
 				})
 			}
 
-			return utils.PrintOutput(data, config.GetOutputFormat())
+			return utils.PrintOutput(data, format)
 		},
 	}
 
+	addPaginationFlags(cmd, &all, &pageSize, &maxItems)
+
 	return cmd
+}
+
+// addPaginationFlags registers the flags shared by paginated listings
+func addPaginationFlags(cmd *cobra.Command, all *bool, pageSize, maxItems *int) {
+	cmd.Flags().BoolVar(all, "all", false, "fetch every page of results")
+	cmd.Flags().IntVar(pageSize, "page-size", 50, "number of services to request per page with --all or --max-items (1-100)")
+	cmd.Flags().IntVar(maxItems, "max-items", 0, "stop after this many services, following pages as needed (0 = no limit)")
+}
+
+// checkPagination rejects pagination flags the pager cannot work with
+func checkPagination(pageSize, maxItems int) error {
+	if pageSize < 1 || pageSize > 100 {
+		return fmt.Errorf("--page-size must be between 1 and 100")
+	}
+	if maxItems < 0 {
+		return fmt.Errorf("--max-items must not be negative")
+	}
+	return nil
+}
+
+// streamServices prints the given columns of each service as pages arrive
+func streamServices(ctx context.Context, pager *api.Pager[api.Service], emptyMessage string, columns ...string) error {
+	printer, err := utils.NewStreamPrinter(config.GetOutputFormat(), columns...)
+	if err != nil {
+		return err
+	}
+
+	for pager.Next(ctx) {
+		service := pager.Item()
+		values := map[string]interface{}{
+			"ID":          service.ID,
+			"Name":        service.Name,
+			"Description": service.Description,
+			"Created":     service.CreatedAt.Format("2006-01-02 15:04:05"),
+			"Updated":     service.UpdatedAt.Format("2006-01-02 15:04:05"),
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			row[column] = values[column]
+		}
+		if err := printer.Print(row); err != nil {
+			return err
+		}
+	}
+
+	// A failed listing is not an empty one
+	err = pager.Err()
+	if err == nil {
+		printer.Empty(emptyMessage)
+	}
+	if closeErr := printer.Close(); closeErr != nil {
+		return closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	return nil
 }
\ No newline at end of file
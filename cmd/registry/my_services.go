@@ -11,6 +11,9 @@ import (
 )
 
 func newMyServicesCmd() *cobra.Command {
+	var all bool
+	var pageSize, maxItems int
+
 	cmd := &cobra.Command{
 		Use:   "my-services",
 		Short: "List your registered services",
@@ -19,27 +22,41 @@ func newMyServicesCmd() *cobra.Command {
   aphelion registry my-services
 
   # List your services in JSON format
-  aphelion registry my-services --output json`,
+  aphelion registry my-services --output json
+
+  # Stream your first 200 services
+  aphelion registry my-services --max-items 200`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !config.IsAuthenticated() {
 				return fmt.Errorf("authentication required. Please run 'aphelion auth login' first")
 			}
+			if err := checkPagination(pageSize, maxItems); err != nil {
+				return err
+			}
 
 			client := api.NewClient()
 			
-			var response api.ServicesResponse
-			if err := client.Get("/owner/services", &response); err != nil {
+			if all || maxItems > 0 {
+				pager := client.Services().ListOwnedPager(pageSize).SetMaxItems(maxItems)
+				return streamServices(cmd.Context(), pager, "No services found", "ID", "Name", "Description", "Created", "Updated")
+			}
+
+			response, err := client.Services().ListOwned(cmd.Context())
+			if err != nil {
 				return fmt.Errorf("failed to list your services: %w", err)
 			}
 
-			if len(response.Services) == 0 {
-				utils.PrintInfo("No services found")
-				return nil
+			// Messages would break JSON and YAML output read by scripts
+			format := config.GetOutputFormat()
+			if format == "table" {
+				if len(response.Services) == 0 {
+					utils.PrintInfo("No services found")
+					return nil
+				}
+				utils.PrintInfo("Found %d services", len(response.Services))
 			}
 
-			utils.PrintInfo("Found %d services", len(response.Services))
-			
-			var data []map[string]interface{}
+			data := []map[string]interface{}{}
 			for _, service := range response.Services {
 				data = append(data, map[string]interface{}{
 					"ID":          service.ID,
@@ -50,9 +67,11 @@ func newMyServicesCmd() *cobra.Command {
 				})
 			}
 
-			return utils.PrintOutput(data, config.GetOutputFormat())
+			return utils.PrintOutput(data, format)
 		},
 	}
 
+	addPaginationFlags(cmd, &all, &pageSize, &maxItems)
+
 	return cmd
 }
\ No newline at end of file
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -11,19 +16,33 @@ import (
 	"github.com/Exmplr-AI/aphelion-cli/cmd/agent"
 	"github.com/Exmplr-AI/aphelion-cli/cmd/analytics"
 	"github.com/Exmplr-AI/aphelion-cli/cmd/auth"
+	configCmd "github.com/Exmplr-AI/aphelion-cli/cmd/config"
+	"github.com/Exmplr-AI/aphelion-cli/cmd/dev"
 	"github.com/Exmplr-AI/aphelion-cli/cmd/memory"
 	"github.com/Exmplr-AI/aphelion-cli/cmd/registry"
 	"github.com/Exmplr-AI/aphelion-cli/cmd/tools"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
 	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
 var (
 	cfgFile   string
+	profile   string
+	account   string
 	apiURL    string
 	output    string
 	verbose   bool
+	debugHTTP bool
+	traceFile string
+	timeout   time.Duration
+	retries   int
+	retryWait time.Duration
 )
 
+// timeoutAnnotation lets a command declare its own default request timeout,
+// used unless a timeout is configured explicitly
+const timeoutAnnotation = "timeout"
+
 var rootCmd = &cobra.Command{
 	Use:   "aphelion",
 	Short: "Aphelion Gateway CLI - Unified AI agent platform",
@@ -58,23 +77,72 @@ and view analytics for your Aphelion Gateway account.`,
 
   # View your usage analytics
   aphelion analytics user`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyCommandTimeout(cmd)
+	},
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	// The first interrupt cancels in-flight requests; restoring the default
+	// handler afterwards lets a second interrupt terminate immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// applyCommandTimeout replaces the default timeout with the command's own.
+// A timeout from a flag, the environment or the config file still wins.
+func applyCommandTimeout(cmd *cobra.Command) error {
+	if _, source, err := config.Value("timeout"); err != nil || source != config.SourceDefault {
+		return err
+	}
+
+	value, ok := cmd.Annotations[timeoutAnnotation]
+	if !ok {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid timeout annotation on %s: %w", cmd.CommandPath(), err)
+	}
+
+	config.SetTimeout(d)
+	return nil
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	api.UserAgent = fmt.Sprintf("aphelion-cli/%s (%s/%s)", version, runtime.GOOS, runtime.GOARCH)
+
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.aphelion/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "configuration profile to use (default is the current context)")
+	rootCmd.PersistentFlags().StringVar(&account, "account", "", "account of the profile to use (default is the current account)")
 	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "https://api.aphelion.exmplr.ai", "API base URL")
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "table", "output format (json|yaml|table)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
-
-	viper.BindPFlag("api-url", rootCmd.PersistentFlags().Lookup("api-url"))
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug-http", false, "dump every API request and response to stderr (credentials redacted)")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "write HTTP debug traces to this file instead of stderr (implies --debug-http)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each API request")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "number of retries for transient API failures (0 disables retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryWait, "retry-max-wait", 30*time.Second, "maximum wait between API retries")
+
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	viper.BindPFlag("account", rootCmd.PersistentFlags().Lookup("account"))
+	viper.BindPFlag("api_url", rootCmd.PersistentFlags().Lookup("api-url"))
 	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("debug_http", rootCmd.PersistentFlags().Lookup("debug-http"))
+	viper.BindPFlag("trace_file", rootCmd.PersistentFlags().Lookup("trace-file"))
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("retries", rootCmd.PersistentFlags().Lookup("retries"))
+	viper.BindPFlag("retry_max_wait", rootCmd.PersistentFlags().Lookup("retry-max-wait"))
 
 	rootCmd.AddCommand(auth.NewAuthCmd())
 	rootCmd.AddCommand(agent.NewAgentCmd())
@@ -82,6 +150,8 @@ func init() {
 	rootCmd.AddCommand(memory.NewMemoryCmd())
 	rootCmd.AddCommand(analytics.NewAnalyticsCmd())
 	rootCmd.AddCommand(tools.NewToolsCmd())
+	rootCmd.AddCommand(dev.NewDevCmd())
+	rootCmd.AddCommand(configCmd.NewConfigCmd())
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newCompletionCmd())
 }
@@ -110,5 +180,6 @@ func initConfig() {
 		fmt.Printf("Using config file: %s\n", viper.ConfigFileUsed())
 	}
 
+	config.SetFlags(rootCmd.PersistentFlags())
 	config.InitConfig()
 }
\ No newline at end of file
@@ -6,26 +6,12 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
 	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
-type ToolDescription struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Version     string                 `json:"version"`
-	Parameters  map[string]interface{} `json:"parameters"`
-	Examples    []ToolExample          `json:"examples,omitempty"`
-	Tags        []string               `json:"tags,omitempty"`
-}
-
-type ToolExample struct {
-	Description string                 `json:"description"`
-	Parameters  map[string]interface{} `json:"parameters"`
-}
-
 func newDescribeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "describe <tool-name>",
@@ -42,18 +28,17 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 	toolName := args[0]
 	
 	client := api.NewClient()
-	endpoint := fmt.Sprintf("/tools/%s/describe", toolName)
 	
-	var toolDesc ToolDescription
-	if err := client.Get(endpoint, &toolDesc); err != nil {
+	toolDesc, err := client.Tools().Describe(cmd.Context(), toolName)
+	if err != nil {
 		return fmt.Errorf("failed to describe tool %s: %w", toolName, err)
 	}
 
-	return outputToolDescription(toolDesc)
+	return outputToolDescription(*toolDesc)
 }
 
-func outputToolDescription(tool ToolDescription) error {
-	outputFormat := viper.GetString("output")
+func outputToolDescription(tool api.ToolDescription) error {
+	outputFormat := config.GetOutputFormat()
 	
 	switch outputFormat {
 	case "json":
@@ -69,7 +54,7 @@ func outputToolDescription(tool ToolDescription) error {
 	}
 }
 
-func outputToolDescriptionTable(tool ToolDescription) error {
+func outputToolDescriptionTable(tool api.ToolDescription) error {
 	fmt.Printf("🔧 Tool: %s\n", tool.Name)
 	fmt.Printf("📝 Description: %s\n", tool.Description)
 	
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
 	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
 )
 
 var (
@@ -17,24 +19,14 @@ var (
 	params string
 )
 
-type ToolExecutionRequest struct {
-	Parameters map[string]interface{} `json:"parameters"`
-}
-
-type ToolExecutionResult struct {
-	Success   bool                   `json:"success"`
-	Result    interface{}            `json:"result,omitempty"`
-	Error     string                 `json:"error,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	Duration  string                 `json:"duration,omitempty"`
-}
-
 func newTryCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "try --tool <tool-name> --params '<json>'",
 		Short: "Execute a tool with given parameters",
 		Long:  "Validate and execute a tool with provided parameters",
-		RunE:  runTry,
+		// Tool executions can run well past the default request timeout
+		Annotations: map[string]string{"timeout": "5m"},
+		RunE:        runTry,
 	}
 
 	cmd.Flags().StringVar(&params, "params", "{}", "Tool parameters as JSON string")
@@ -58,21 +50,15 @@ func runTry(cmd *cobra.Command, args []string) error {
 	client := api.NewClient()
 
 	if dryRun {
-		return validateToolParameters(client, toolName, parameters)
+		return validateToolParameters(cmd.Context(), client, toolName, parameters)
 	}
 
-	return executeToolWithParameters(client, toolName, parameters)
+	return executeToolWithParameters(cmd.Context(), client, toolName, parameters)
 }
 
-func validateToolParameters(client *api.Client, toolName string, parameters map[string]interface{}) error {
-	endpoint := fmt.Sprintf("/tools/%s/validate", toolName)
-	
-	request := ToolExecutionRequest{
-		Parameters: parameters,
-	}
-
-	var result map[string]interface{}
-	if err := client.Post(endpoint, request, &result); err != nil {
+func validateToolParameters(ctx context.Context, client *api.Client, toolName string, parameters map[string]interface{}) error {
+	result, err := client.Tools().Validate(ctx, toolName, parameters)
+	if err != nil {
 		return fmt.Errorf("parameter validation failed: %w", err)
 	}
 
@@ -86,7 +72,7 @@ func validateToolParameters(client *api.Client, toolName string, parameters map[
 	return nil
 }
 
-func executeToolWithParameters(client *api.Client, toolName string, parameters map[string]interface{}) error {
+func executeToolWithParameters(ctx context.Context, client *api.Client, toolName string, parameters map[string]interface{}) error {
 	fmt.Printf("🚀 Executing tool: %s\n", toolName)
 	
 	if viper.GetBool("verbose") {
@@ -100,24 +86,18 @@ func executeToolWithParameters(client *api.Client, toolName string, parameters m
 	spinner.Start()
 	defer spinner.Stop()
 
-	endpoint := fmt.Sprintf("/tools/%s/execute", toolName)
-	
-	request := ToolExecutionRequest{
-		Parameters: parameters,
-	}
-
-	var result ToolExecutionResult
-	if err := client.Post(endpoint, request, &result); err != nil {
+	result, err := client.Tools().Execute(ctx, toolName, parameters)
+	if err != nil {
 		spinner.Stop()
 		return fmt.Errorf("tool execution failed: %w", err)
 	}
 
 	spinner.Stop()
-	return outputExecutionResult(result)
+	return outputExecutionResult(*result)
 }
 
-func outputExecutionResult(result ToolExecutionResult) error {
-	outputFormat := viper.GetString("output")
+func outputExecutionResult(result api.ToolExecutionResult) error {
+	outputFormat := config.GetOutputFormat()
 	
 	switch outputFormat {
 	case "json":
@@ -133,7 +113,7 @@ func outputExecutionResult(result ToolExecutionResult) error {
 	}
 }
 
-func outputExecutionResultTable(result ToolExecutionResult) error {
+func outputExecutionResultTable(result api.ToolExecutionResult) error {
 	if result.Success {
 		fmt.Println("✅ Tool executed successfully")
 	} else {
@@ -0,0 +1,859 @@
+// Package mockgateway implements an in-process stand-in for the Aphelion
+// Gateway that serves the endpoints used by the CLI from a local store.
+package mockgateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/auth"
+)
+
+const (
+	mockClientID = "mock-client"
+	mockAudience = "https://mock-gateway.aphelion.local"
+	// mockClientSecret is accepted for any client ID in the client
+	// credentials grant
+	mockClientSecret = "mock-secret"
+	tokenPrefix      = "mock-token-"
+	refreshPrefix    = "mock-refresh-"
+	defaultUser      = "dev"
+)
+
+// Server serves the mock gateway API
+type Server struct {
+	store  *Store
+	mux    *http.ServeMux
+	logger io.Writer
+
+	// tokenTTL limits the lifetime of access tokens issued by /oauth/token
+	tokenTTL time.Duration
+	mu       sync.Mutex
+	issued   int
+	devices  map[string]*deviceLogin
+	revoked  map[string]bool
+}
+
+// deviceLogin is a pending device authorization, approved through /activate
+type deviceLogin struct {
+	userCode string
+	user     string
+	expires  time.Time
+}
+
+// NewServer creates a mock gateway backed by store. When logger is non-nil
+// every request is logged to it.
+func NewServer(store *Store, logger io.Writer) *Server {
+	s := &Server{
+		store:   store,
+		mux:     http.NewServeMux(),
+		logger:  logger,
+		devices: map[string]*deviceLogin{},
+		revoked: map[string]bool{},
+	}
+
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/authorize", s.handleAuthorize)
+	s.mux.HandleFunc("/oauth/token", s.handleToken)
+	s.mux.HandleFunc("/oauth/revoke", s.handleRevoke)
+	s.mux.HandleFunc("/oauth/device/code", s.handleDeviceCode)
+	s.mux.HandleFunc("/activate", s.handleActivate)
+	s.mux.HandleFunc("/userinfo", s.handleUserInfo)
+	s.mux.HandleFunc("/auth/", s.handleAuth)
+	s.mux.HandleFunc("/services", s.handleServices)
+	s.mux.HandleFunc("/services/", s.handleService)
+	s.mux.HandleFunc("/owner/services", s.handleOwnerServices)
+	s.mux.HandleFunc("/owner/services/", s.handleOwnerService)
+	s.mux.HandleFunc("/tools/", s.handleTool)
+	s.mux.HandleFunc("/search/tools", s.handleSearchTools)
+	s.mux.HandleFunc("/sessions", s.handleSessions)
+	s.mux.HandleFunc("/memory", s.handleMemory)
+	s.mux.HandleFunc("/memory/", s.handleMemorySub)
+	s.mux.HandleFunc("/analytics/", s.handleAnalytics)
+
+	return s
+}
+
+// SetTokenTTL makes access tokens issued by /oauth/token expire after ttl,
+// so clients can exercise token refresh. Zero keeps tokens valid forever.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.tokenTTL = ttl
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	s.mux.ServeHTTP(rec, r)
+
+	s.store.countRequest(rec.status >= 400)
+	if s.logger != nil {
+		fmt.Fprintf(s.logger, "%s %s %s %d %s\n", start.Format("15:04:05"), r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Microsecond))
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]interface{}{
+		"status_code": status,
+		"error":       fmt.Sprintf(format, args...),
+	})
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "method %s not allowed on %s", r.Method, r.URL.Path)
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// userID returns the user identified by the bearer token. Any token is
+// accepted except expired or revoked ones; tokens issued by the mock map back
+// to their user.
+func (s *Server) userID(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == "" || token == header {
+		return "", false
+	}
+
+	if claims, err := auth.DecodeJWT(token); err == nil && claims.Subject != "" {
+		return claims.Subject, time.Now().Before(claims.Expiry())
+	}
+	if user, found, valid := s.store.TokenUser(token); found {
+		return user, valid
+	}
+
+	if strings.HasPrefix(token, tokenPrefix) {
+		return strings.TrimPrefix(token, tokenPrefix), true
+	}
+	return defaultUser, true
+}
+
+// requireUser writes a 401 response and returns false when the request is unauthenticated
+func (s *Server) requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, ok := s.userID(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "authentication required")
+		return "", false
+	}
+	s.store.User(id, id, id+"@mock.aphelion.local")
+	return id, true
+}
+
+func baseURL(r *http.Request) string {
+	return "http://" + r.Host
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "mock": true})
+}
+
+// handleAuthorize completes the browser login immediately by redirecting back
+// with an authorization code
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		writeError(w, http.StatusBadRequest, "redirect_uri is required")
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("code", "mock-code-"+defaultUser)
+	if state := query.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form: %v", err)
+		return
+	}
+
+	switch grant := r.PostForm.Get("grant_type"); grant {
+	case "authorization_code":
+		user := strings.TrimPrefix(r.PostForm.Get("code"), "mock-code-")
+		writeJSON(w, http.StatusOK, s.issueToken(r, user))
+	case "refresh_token":
+		user, ok := s.refreshUser(r.PostForm.Get("refresh_token"))
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":             "invalid_grant",
+				"error_description": "unknown or revoked refresh token",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.issueToken(r, user))
+	case "client_credentials":
+		clientID := r.PostForm.Get("client_id")
+		if clientID == "" || r.PostForm.Get("client_secret") != mockClientSecret {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"error":             "invalid_client",
+				"error_description": "unknown client or wrong client secret",
+			})
+			return
+		}
+		// Service accounts are not issued refresh tokens
+		token := s.issueToken(r, clientID+"@clients")
+		delete(token, "refresh_token")
+		writeJSON(w, http.StatusOK, token)
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		s.mu.Lock()
+		device, ok := s.devices[r.PostForm.Get("device_code")]
+		s.mu.Unlock()
+		switch {
+		case !ok || time.Now().After(device.expires):
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "expired_token"})
+		case device.user == "":
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "authorization_pending"})
+		default:
+			writeJSON(w, http.StatusOK, s.issueToken(r, device.user))
+		}
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":             "unsupported_grant_type",
+			"error_description": fmt.Sprintf("grant type %q is not supported by the mock gateway", grant),
+		})
+	}
+}
+
+// handleRevoke revokes a refresh token. As RFC 7009 requires, unknown tokens
+// are accepted without an error.
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form: %v", err)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":             "invalid_request",
+			"error_description": "token is required",
+		})
+		return
+	}
+
+	s.mu.Lock()
+	s.revoked[token] = true
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// refreshUser returns the user a refresh token was issued to, unless it is
+// unknown or revoked
+func (s *Server) refreshUser(refresh string) (string, bool) {
+	s.mu.Lock()
+	revoked := s.revoked[refresh]
+	s.mu.Unlock()
+
+	i := strings.LastIndex(refresh, ".")
+	if revoked || !strings.HasPrefix(refresh, refreshPrefix) || i < len(refreshPrefix) {
+		return "", false
+	}
+	return refresh[len(refreshPrefix):i], true
+}
+
+// handleDeviceCode starts a device login. It is approved by visiting
+// /activate?user_code=<code>, optionally with &user=<name>.
+func (s *Server) handleDeviceCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	n := len(s.devices) + 1
+	deviceCode := fmt.Sprintf("mock-device-%d", n)
+	userCode := fmt.Sprintf("MOCK-%04d", n)
+	s.devices[deviceCode] = &deviceLogin{userCode: userCode, expires: time.Now().Add(10 * time.Minute)}
+	s.mu.Unlock()
+
+	verificationURI := baseURL(r) + "/activate"
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?user_code=" + userCode,
+		"expires_in":                600,
+		"interval":                  1,
+	})
+}
+
+// handleActivate approves the device login with the given user code
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request) {
+	userCode := r.URL.Query().Get("user_code")
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		user = defaultUser
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, device := range s.devices {
+		if device.userCode == userCode {
+			device.user = user
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "Device login approved for %s. You can return to the terminal.\n", user)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "unknown user code %q", userCode)
+}
+
+// issueToken returns a token response for user. Access tokens are unsigned
+// JWTs that stop being accepted once they expire, after an hour or the
+// configured token TTL.
+func (s *Server) issueToken(r *http.Request, user string) map[string]interface{} {
+	ttl := time.Hour
+	if s.tokenTTL > 0 {
+		ttl = s.tokenTTL
+	}
+
+	s.mu.Lock()
+	s.issued++
+	id := s.issued
+	s.mu.Unlock()
+
+	now := time.Now()
+	token := unsignedJWT(map[string]interface{}{
+		"iss":   baseURL(r) + "/",
+		"sub":   user,
+		"aud":   []string{mockAudience, baseURL(r) + "/userinfo"},
+		"iat":   now.Unix(),
+		"exp":   now.Add(ttl).Unix(),
+		"jti":   fmt.Sprintf("mock-%d", id),
+		"scope": auth.Scopes,
+	})
+
+	return map[string]interface{}{
+		"access_token":  token,
+		"refresh_token": fmt.Sprintf("%s%s.%d", refreshPrefix, user, id),
+		"token_type":    "Bearer",
+		"expires_in":    int(ttl / time.Second),
+	}
+}
+
+// unsignedJWT encodes claims as a JWT with the "none" algorithm
+func unsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":   id,
+		"name":  id,
+		"email": id + "@mock.aphelion.local",
+	})
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/auth/")
+	if path == "tokens" || strings.HasPrefix(path, "tokens/") {
+		s.handleTokens(w, r, strings.TrimPrefix(strings.TrimPrefix(path, "tokens"), "/"))
+		return
+	}
+
+	switch path {
+	case "info":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"auth0_domain":   r.Host,
+			"client_id":      mockClientID,
+			"auth0_audience": mockAudience,
+			"auth_url":       baseURL(r) + "/authorize",
+		})
+
+	case "login", "register":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r)
+			return
+		}
+		var req api.RegisterRequest
+		if err := decodeBody(r, &req); err != nil || req.Username == "" {
+			writeError(w, http.StatusBadRequest, "username and password are required")
+			return
+		}
+		email := req.Email
+		if email == "" {
+			email = req.Username + "@mock.aphelion.local"
+		}
+		user := s.store.User(req.Username, req.Username, email)
+		writeJSON(w, http.StatusOK, api.AuthResponse{Token: tokenPrefix + user.ID, User: user})
+
+	case "profile":
+		id, ok := s.requireUser(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, s.store.User(id, "", ""))
+
+	case "test-profile":
+		id, ok := s.requireUser(w, r)
+		if !ok {
+			return
+		}
+		user := s.store.User(id, "", "")
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"user": map[string]interface{}{
+				"sub":   user.ID,
+				"name":  user.Username,
+				"email": user.Email,
+			},
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "unknown auth endpoint %s", r.URL.Path)
+	}
+}
+
+// handleTokens serves /auth/tokens and /auth/tokens/{id}
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request, id string) {
+	userID, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, api.TokensResponse{Tokens: s.store.Tokens(userID)})
+	case id == "" && r.Method == http.MethodPost:
+		var req api.CreateTokenRequest
+		if err := decodeBody(r, &req); err != nil || req.Name == "" {
+			writeError(w, http.StatusBadRequest, "name is required")
+			return
+		}
+		writeJSON(w, http.StatusCreated, s.store.CreateToken(userID, req))
+	case id != "" && r.Method == http.MethodDelete:
+		if !s.store.RevokeToken(userID, id) {
+			writeError(w, http.StatusNotFound, "token %s not found", id)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// pageParams reads limit and offset query parameters
+func pageParams(r *http.Request, defaultLimit int) (int, int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func pageServices(services []api.Service, r *http.Request) api.ServicesResponse {
+	limit, offset := pageParams(r, len(services))
+	total := len(services)
+
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return api.ServicesResponse{Services: services[offset:end], Total: total}
+}
+
+func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, pageServices(s.store.Services(""), r))
+}
+
+func (s *Server) handleService(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/services/"), "/")
+	switch sub {
+	case "":
+		service, ok := s.store.Service(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "service %s not found", id)
+			return
+		}
+		writeJSON(w, http.StatusOK, service)
+	case "manifest":
+		manifest, ok := s.store.Manifest(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "service %s not found", id)
+			return
+		}
+		writeJSON(w, http.StatusOK, manifest)
+	default:
+		writeError(w, http.StatusNotFound, "unknown service endpoint %s", r.URL.Path)
+	}
+}
+
+func (s *Server) handleOwnerServices(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, pageServices(s.store.Services(owner), r))
+	case http.MethodPost:
+		var req map[string]interface{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+			return
+		}
+		if name, _ := req["name"].(string); name == "" {
+			writeError(w, http.StatusBadRequest, "service name is required")
+			return
+		}
+		service := s.store.CreateService(owner, req)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"service": service})
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleOwnerService(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/owner/services/")
+	if !s.store.DeleteService(owner, id) {
+		writeError(w, http.StatusNotFound, "service %s not found", id)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": id})
+}
+
+func (s *Server) handleTool(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/tools/")
+	slash := strings.LastIndex(rest, "/")
+	if slash < 0 {
+		writeError(w, http.StatusNotFound, "unknown tool endpoint %s", r.URL.Path)
+		return
+	}
+	name, action := rest[:slash], rest[slash+1:]
+
+	tool, ok := s.store.Tool(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "tool %s not found", name)
+		return
+	}
+
+	switch action {
+	case "describe":
+		writeJSON(w, http.StatusOK, tool)
+	case "validate", "execute":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r)
+			return
+		}
+		var req struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+			return
+		}
+
+		missing := missingParameters(tool, req.Parameters)
+		if action == "validate" {
+			if len(missing) > 0 {
+				writeError(w, http.StatusUnprocessableEntity, "missing required parameters: %s", strings.Join(missing, ", "))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"valid": true, "tool": tool.Name})
+			return
+		}
+
+		userID, _ := s.userID(r)
+		start := time.Now()
+		result := map[string]interface{}{"success": len(missing) == 0}
+		if len(missing) > 0 {
+			result["error"] = "missing required parameters: " + strings.Join(missing, ", ")
+		} else {
+			result["result"] = map[string]interface{}{
+				"tool":       tool.Name,
+				"parameters": req.Parameters,
+				"mock":       true,
+			}
+		}
+		result["duration"] = time.Since(start).String()
+		s.store.RecordExecution(tool.Name, userID, len(missing) == 0)
+		writeJSON(w, http.StatusOK, result)
+	default:
+		writeError(w, http.StatusNotFound, "unknown tool endpoint %s", r.URL.Path)
+	}
+}
+
+// missingParameters returns required parameters absent from params. Both a
+// JSON schema "required" list and per-property "required": true are honored.
+func missingParameters(tool Tool, params map[string]interface{}) []string {
+	required := map[string]bool{}
+	if list, ok := tool.Parameters["required"].([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	for name, schema := range tool.Parameters {
+		if schemaMap, ok := schema.(map[string]interface{}); ok {
+			if req, ok := schemaMap["required"].(bool); ok && req {
+				required[name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for name := range required {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func (s *Server) handleSearchTools(w http.ResponseWriter, r *http.Request) {
+	tools := s.store.SearchTools(r.URL.Query().Get("q"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tools": tools, "total": len(tools)})
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"session_id": s.store.CreateSession(userID)})
+}
+
+func (s *Server) handleMemory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		memories := s.store.Memories(userID, false)
+		writeJSON(w, http.StatusOK, api.MemoriesResponse{Memories: memories, Total: len(memories)})
+	case http.MethodPost:
+		var memory api.Memory
+		if err := decodeBody(r, &memory); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, s.store.SaveMemory(userID, memory))
+	case http.MethodDelete:
+		removed := s.store.ClearMemories(userID, r.URL.Query().Get("session_id"))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": removed})
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleMemorySub(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	switch strings.TrimPrefix(r.URL.Path, "/memory/") {
+	case "paginated":
+		memories := filterMemoriesByDate(s.store.Memories(userID, query.Get("sort") == "oldest"), query.Get("date_from"), query.Get("date_to"))
+
+		limit, offset := pageParams(r, 10)
+		if cursor := query.Get("cursor"); cursor != "" {
+			if n, err := strconv.Atoi(cursor); err == nil && n >= 0 {
+				offset = n
+			}
+		}
+
+		total := len(memories)
+		if offset > total {
+			offset = total
+		}
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+
+		resp := api.MemoriesResponse{Memories: memories[offset:end], Total: total}
+		if end < total {
+			resp.Cursor = strconv.Itoa(end)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case "search":
+		limit, _ := pageParams(r, 10)
+		threshold, err := strconv.ParseFloat(query.Get("threshold"), 64)
+		if err != nil {
+			threshold = 0
+		}
+
+		results := []api.Memory{}
+		for _, memory := range s.store.Memories(userID, false) {
+			memory.Similarity = similarity(query.Get("q"), memory)
+			if memory.Similarity >= threshold && len(results) < limit {
+				results = append(results, memory)
+			}
+		}
+		writeJSON(w, http.StatusOK, results)
+
+	case "stats":
+		memories := s.store.Memories(userID, true)
+		stats := api.MemoryStats{TotalMemories: len(memories)}
+
+		sessions := map[string]bool{}
+		for _, memory := range memories {
+			sessions[memory.SessionID] = true
+		}
+		stats.TotalSessions = len(sessions)
+
+		if len(memories) > 0 {
+			oldest, newest := memories[0].CreatedAt, memories[len(memories)-1].CreatedAt
+			stats.OldestMemory = oldest.Format(time.RFC3339)
+			stats.MostRecentMemory = newest.Format(time.RFC3339)
+			days := newest.Sub(oldest).Hours()/24 + 1
+			stats.AveragePerDay = float64(len(memories)) / days
+		}
+		writeJSON(w, http.StatusOK, stats)
+
+	default:
+		writeError(w, http.StatusNotFound, "unknown memory endpoint %s", r.URL.Path)
+	}
+}
+
+// filterMemoriesByDate keeps memories created within the YYYY-MM-DD range
+func filterMemoriesByDate(memories []api.Memory, from, to string) []api.Memory {
+	fromDate, fromErr := time.Parse("2006-01-02", from)
+	toDate, toErr := time.Parse("2006-01-02", to)
+	if fromErr != nil && toErr != nil {
+		return memories
+	}
+
+	filtered := []api.Memory{}
+	for _, memory := range memories {
+		if fromErr == nil && memory.CreatedAt.Before(fromDate) {
+			continue
+		}
+		if toErr == nil && !memory.CreatedAt.Before(toDate.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, memory)
+	}
+	return filtered
+}
+
+// similarity scores a memory by the fraction of query words found in its summary and content
+func similarity(query string, memory api.Memory) float64 {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 0
+	}
+
+	content, _ := json.Marshal(memory.Content)
+	haystack := strings.ToLower(memory.Summary + " " + string(content))
+
+	matched := 0
+	for _, word := range words {
+		if strings.Contains(haystack, word) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(words))
+}
+
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	scope := userID
+	switch strings.TrimPrefix(r.URL.Path, "/analytics/") {
+	case "user":
+	case "tools", "sessions":
+		if r.URL.Query().Get("user_only") != "true" {
+			scope = ""
+		}
+	default:
+		writeError(w, http.StatusNotFound, "unknown analytics endpoint %s", r.URL.Path)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.store.Analytics(scope))
+}
@@ -0,0 +1,565 @@
+package mockgateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+)
+
+// Tool is a tool definition served by the mock gateway
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Version     string                 `json:"version,omitempty"`
+	Service     string                 `json:"service,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Tags        []string               `json:"tags,omitempty"`
+}
+
+// Execution records a tool execution for analytics
+type Execution struct {
+	Tool    string    `json:"tool"`
+	UserID  string    `json:"user_id"`
+	Success bool      `json:"success"`
+	At      time.Time `json:"at"`
+}
+
+// Token is a personal access token issued by the mock
+type Token struct {
+	api.PersonalAccessToken
+	Secret  string `json:"secret"`
+	UserID  string `json:"user_id"`
+	Revoked bool   `json:"revoked,omitempty"`
+}
+
+// state is the persisted content of the store
+type state struct {
+	Services   []api.Service          `json:"services"`
+	Owners     map[string]string      `json:"owners"`
+	Manifests  map[string]interface{} `json:"manifests"`
+	Tools      []Tool                 `json:"tools"`
+	Memories   []api.Memory           `json:"memories"`
+	Users      []api.User             `json:"users"`
+	Sessions   map[string]string      `json:"sessions"`
+	Executions []Execution            `json:"executions"`
+	Tokens     []Token                `json:"tokens,omitempty"`
+	Requests   int                    `json:"requests"`
+	Errors     int                    `json:"errors"`
+	NextID     int                    `json:"next_id"`
+}
+
+// Store is an in-memory data store, optionally persisted to a JSON file
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data state
+}
+
+// NewStore creates a store seeded from the fixtures directory. When path
+// points to an existing store file its content is loaded instead, and every
+// change is written back to it.
+func NewStore(fixturesDir, path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: state{
+			Owners:    map[string]string{},
+			Manifests: map[string]interface{}{},
+			Sessions:  map[string]string{},
+			NextID:    1,
+		},
+	}
+
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err == nil {
+			if err := json.Unmarshal(raw, &s.data); err != nil {
+				return nil, fmt.Errorf("failed to parse store file %s: %w", path, err)
+			}
+			s.ensureMaps()
+			return s, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read store file %s: %w", path, err)
+		}
+	}
+
+	if fixturesDir != "" {
+		if err := s.loadFixtures(fixturesDir); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, s.save()
+}
+
+// loadFixtures seeds the store from services.json, manifests.json,
+// tools.json, memories.json and users.json in dir. Missing files are skipped.
+func (s *Store) loadFixtures(dir string) error {
+	fixtures := map[string]interface{}{
+		"services.json":  &s.data.Services,
+		"manifests.json": &s.data.Manifests,
+		"tools.json":     &s.data.Tools,
+		"memories.json":  &s.data.Memories,
+		"users.json":     &s.data.Users,
+	}
+
+	for name, target := range fixtures {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read fixture %s: %w", name, err)
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("failed to parse fixture %s: %w", name, err)
+		}
+	}
+
+	s.ensureMaps()
+	return nil
+}
+
+func (s *Store) ensureMaps() {
+	if s.data.Owners == nil {
+		s.data.Owners = map[string]string{}
+	}
+	if s.data.Manifests == nil {
+		s.data.Manifests = map[string]interface{}{}
+	}
+	if s.data.Sessions == nil {
+		s.data.Sessions = map[string]string{}
+	}
+	if s.data.NextID == 0 {
+		s.data.NextID = 1
+	}
+}
+
+// save writes the store to disk when a store file is configured. Callers must hold s.mu
+// or be the only user of the store.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, raw, 0600); err != nil {
+		return fmt.Errorf("failed to write store file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *Store) nextID(prefix string) string {
+	id := fmt.Sprintf("%s-%d", prefix, s.data.NextID)
+	s.data.NextID++
+	return id
+}
+
+func (s *Store) countRequest(failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Requests++
+	if failed {
+		s.data.Errors++
+	}
+}
+
+// User returns the user with the given ID, creating it on first use
+func (s *Store) User(id, username, email string) api.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.data.Users {
+		if user.ID == id || (username != "" && user.Username == username) {
+			return user
+		}
+	}
+
+	user := api.User{ID: id, Username: username, Email: email}
+	s.data.Users = append(s.data.Users, user)
+	s.save()
+	return user
+}
+
+// Services returns all services, or only those owned by owner when it is non-empty
+func (s *Store) Services(owner string) []api.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	services := []api.Service{}
+	for _, service := range s.data.Services {
+		if owner == "" || s.data.Owners[service.ID] == owner {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func (s *Store) Service(id string) (api.Service, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, service := range s.data.Services {
+		if service.ID == id {
+			return service, true
+		}
+	}
+	return api.Service{}, false
+}
+
+// Manifest returns the recorded manifest of a service, or a generated one
+func (s *Store) Manifest(id string) (interface{}, bool) {
+	service, ok := s.Service(id)
+	if !ok {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if manifest, ok := s.data.Manifests[id]; ok {
+		return manifest, true
+	}
+
+	tools := []Tool{}
+	for _, tool := range s.data.Tools {
+		if tool.Service == service.Name || tool.Service == service.ID {
+			tools = append(tools, tool)
+		}
+	}
+
+	return map[string]interface{}{
+		"name":        service.Name,
+		"description": service.Description,
+		"tools":       tools,
+	}, true
+}
+
+// CreateService registers a service owned by owner. A manifest with tools is
+// stored as-is and its tools become executable.
+func (s *Store) CreateService(owner string, req map[string]interface{}) api.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	service := api.Service{
+		ID:        s.nextID("svc"),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	service.Name, _ = req["name"].(string)
+	service.Description, _ = req["description"].(string)
+	service.Spec, _ = req["spec"].(map[string]interface{})
+
+	if rawTools, ok := req["tools"].([]interface{}); ok {
+		s.data.Manifests[service.ID] = req
+		for _, raw := range rawTools {
+			toolMap, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			tool := Tool{Service: service.Name}
+			tool.Name, _ = toolMap["name"].(string)
+			tool.Description, _ = toolMap["description"].(string)
+			tool.Parameters, _ = toolMap["parameters"].(map[string]interface{})
+			if tool.Name != "" {
+				s.data.Tools = append(s.data.Tools, tool)
+			}
+		}
+	}
+
+	s.data.Services = append(s.data.Services, service)
+	s.data.Owners[service.ID] = owner
+	s.save()
+	return service
+}
+
+// DeleteService removes a service owned by owner
+func (s *Store) DeleteService(owner, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, service := range s.data.Services {
+		if service.ID == id && s.data.Owners[id] == owner {
+			s.data.Services = append(s.data.Services[:i], s.data.Services[i+1:]...)
+			delete(s.data.Owners, id)
+			delete(s.data.Manifests, id)
+			s.save()
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) Tool(name string) (Tool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tool := range s.data.Tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// SearchTools returns tools whose name, description or tags contain query
+func (s *Store) SearchTools(query string) []Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query = strings.ToLower(query)
+	tools := []Tool{}
+	for _, tool := range s.data.Tools {
+		haystack := strings.ToLower(tool.Name + " " + tool.Description + " " + strings.Join(tool.Tags, " "))
+		if query == "" || strings.Contains(haystack, query) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (s *Store) RecordExecution(tool, userID string, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Executions = append(s.data.Executions, Execution{
+		Tool:    tool,
+		UserID:  userID,
+		Success: success,
+		At:      time.Now().UTC(),
+	})
+	s.save()
+}
+
+// CreateSession starts a new agent session for the user
+func (s *Store) CreateSession(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("session")
+	s.data.Sessions[id] = userID
+	s.save()
+	return id
+}
+
+// Memories returns the user's memories, newest first unless oldest is set
+func (s *Store) Memories(userID string, oldest bool) []api.Memory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memories := []api.Memory{}
+	for _, memory := range s.data.Memories {
+		if owner := s.memoryOwner(memory); owner == userID || owner == "" {
+			memories = append(memories, memory)
+		}
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		if oldest {
+			return memories[i].CreatedAt.Before(memories[j].CreatedAt)
+		}
+		return memories[i].CreatedAt.After(memories[j].CreatedAt)
+	})
+	return memories
+}
+
+// memoryOwner resolves the owner of a memory through its session. Memories
+// without a known session belong to every user, which keeps fixtures simple.
+func (s *Store) memoryOwner(memory api.Memory) string {
+	if owner, ok := s.data.Sessions[memory.SessionID]; ok {
+		return owner
+	}
+	return ""
+}
+
+func (s *Store) SaveMemory(userID string, memory api.Memory) api.Memory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memory.ID = s.nextID("mem")
+	memory.CreatedAt = time.Now().UTC()
+	if memory.SessionID == "" {
+		memory.SessionID = s.nextID("session")
+	}
+	if _, ok := s.data.Sessions[memory.SessionID]; !ok {
+		s.data.Sessions[memory.SessionID] = userID
+	}
+
+	s.data.Memories = append(s.data.Memories, memory)
+	s.save()
+	return memory
+}
+
+// ClearMemories deletes the user's memories, optionally limited to one session
+func (s *Store) ClearMemories(userID, sessionID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.data.Memories[:0]
+	removed := 0
+	for _, memory := range s.data.Memories {
+		owner := s.memoryOwner(memory)
+		if (owner == userID || owner == "") && (sessionID == "" || memory.SessionID == sessionID) {
+			removed++
+			continue
+		}
+		kept = append(kept, memory)
+	}
+	s.data.Memories = kept
+	s.save()
+	return removed
+}
+
+// Analytics computes usage metrics, limited to userID when it is non-empty
+func (s *Store) Analytics(userID string) api.Analytics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var analytics api.Analytics
+
+	analytics.RequestMetrics.TotalRequests = s.data.Requests
+	analytics.RequestMetrics.ErrorCount = s.data.Errors
+	analytics.RequestMetrics.SuccessfulCount = s.data.Requests - s.data.Errors
+	if s.data.Requests > 0 {
+		analytics.RequestMetrics.SuccessRate = float64(analytics.RequestMetrics.SuccessfulCount) / float64(s.data.Requests)
+	}
+
+	usage := map[string]*api.ToolUsage{}
+	successes := map[string]int{}
+	users := map[string]bool{}
+	for _, execution := range s.data.Executions {
+		users[execution.UserID] = true
+		if userID != "" && execution.UserID != userID {
+			continue
+		}
+		if usage[execution.Tool] == nil {
+			usage[execution.Tool] = &api.ToolUsage{Tool: execution.Tool}
+		}
+		usage[execution.Tool].Count++
+		if execution.Success {
+			successes[execution.Tool]++
+		}
+		analytics.ToolMetrics.TotalExecutions++
+	}
+
+	for name, tool := range usage {
+		tool.SuccessRate = float64(successes[name]) / float64(tool.Count)
+		analytics.ToolMetrics.PopularTools = append(analytics.ToolMetrics.PopularTools, *tool)
+	}
+	sort.Slice(analytics.ToolMetrics.PopularTools, func(i, j int) bool {
+		return analytics.ToolMetrics.PopularTools[i].Count > analytics.ToolMetrics.PopularTools[j].Count
+	})
+	analytics.ToolMetrics.UniqueTools = len(usage)
+
+	activities := 0
+	for session, owner := range s.data.Sessions {
+		if userID != "" && owner != userID {
+			continue
+		}
+		analytics.SessionMetrics.TotalSessions++
+		for _, memory := range s.data.Memories {
+			if memory.SessionID == session {
+				activities++
+			}
+		}
+	}
+	analytics.SessionMetrics.ActiveSessions = analytics.SessionMetrics.TotalSessions
+	if analytics.SessionMetrics.TotalSessions > 0 {
+		analytics.SessionMetrics.AverageActivities = float64(activities) / float64(analytics.SessionMetrics.TotalSessions)
+	}
+
+	analytics.UserMetrics.UniqueUsers = len(s.data.Users)
+	analytics.UserMetrics.ActiveUsers = len(users)
+
+	return analytics
+}
+
+// CreateToken issues a personal access token for userID
+func (s *Store) CreateToken(userID string, req api.CreateTokenRequest) api.CreateTokenResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secret := fmt.Sprintf("aph_pat_%d%x", s.data.NextID, time.Now().UnixNano())
+	token := Token{
+		PersonalAccessToken: api.PersonalAccessToken{
+			ID:        s.nextID("tok"),
+			Name:      req.Name,
+			Scopes:    req.Scopes,
+			Prefix:    secret[:12],
+			CreatedAt: time.Now().UTC(),
+		},
+		Secret: secret,
+		UserID: userID,
+	}
+	if req.ExpiresIn > 0 {
+		expires := token.CreatedAt.Add(time.Duration(req.ExpiresIn) * time.Second)
+		token.ExpiresAt = &expires
+	}
+
+	s.data.Tokens = append(s.data.Tokens, token)
+	s.save()
+	return api.CreateTokenResponse{PersonalAccessToken: token.PersonalAccessToken, Token: secret}
+}
+
+// Tokens returns the active personal access tokens of userID
+func (s *Store) Tokens(userID string) []api.PersonalAccessToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := []api.PersonalAccessToken{}
+	for _, token := range s.data.Tokens {
+		if token.UserID == userID && !token.Revoked {
+			tokens = append(tokens, token.PersonalAccessToken)
+		}
+	}
+	return tokens
+}
+
+// RevokeToken revokes a personal access token of userID, reporting whether
+// it existed
+func (s *Store) RevokeToken(userID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, token := range s.data.Tokens {
+		if token.ID == id && token.UserID == userID && !token.Revoked {
+			s.data.Tokens[i].Revoked = true
+			s.save()
+			return true
+		}
+	}
+	return false
+}
+
+// TokenUser looks up a personal access token by its secret. found reports
+// whether the mock issued it and valid whether it may still be used.
+func (s *Store) TokenUser(secret string) (userID string, found, valid bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, token := range s.data.Tokens {
+		if token.Secret != secret {
+			continue
+		}
+		if token.Revoked || (token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt)) {
+			return token.UserID, true, false
+		}
+		now := time.Now().UTC()
+		s.data.Tokens[i].LastUsedAt = &now
+		return token.UserID, true, true
+	}
+	return "", false, false
+}
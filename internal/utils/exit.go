@@ -0,0 +1,30 @@
+package utils
+
+import "fmt"
+
+// Exit codes with a meaning beyond failure, for use in scripts
+const (
+	// ExitNotAuthenticated means there are no credentials
+	ExitNotAuthenticated = 2
+	// ExitTokenInvalid means the credentials are expired or were rejected
+	ExitTokenInvalid = 3
+)
+
+// ExitError makes the CLI exit with Code instead of the default 1
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// NewExitError returns an error that exits with code
+func NewExitError(code int, format string, args ...interface{}) *ExitError {
+	return &ExitError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
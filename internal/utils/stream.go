@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// streamFlushRows is how many table rows are buffered for column alignment
+// before they are written out
+const streamFlushRows = 50
+
+// StreamPrinter writes rows as they arrive instead of buffering the whole
+// result set. JSON output is a single array, YAML a list and tables print
+// their header once, flushing every streamFlushRows rows.
+type StreamPrinter struct {
+	out     io.Writer
+	format  string
+	columns []string
+	table   *tabwriter.Writer
+	rows    int
+	empty   string
+	closed  bool
+}
+
+// NewStreamPrinter returns a printer for format whose table columns, in
+// order, are columns. Rows are maps keyed by column name.
+func NewStreamPrinter(format string, columns ...string) (*StreamPrinter, error) {
+	switch format {
+	case "json", "yaml", "table":
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	return &StreamPrinter{
+		out:     os.Stdout,
+		format:  format,
+		columns: columns,
+	}, nil
+}
+
+// Empty sets the message shown in place of a table without rows
+func (p *StreamPrinter) Empty(format string, args ...interface{}) *StreamPrinter {
+	p.empty = fmt.Sprintf(format, args...)
+	return p
+}
+
+// Print writes a single row. A failed row closes the output, so that callers
+// returning the error still leave a complete JSON array behind.
+func (p *StreamPrinter) Print(row map[string]interface{}) error {
+	if p.closed {
+		return fmt.Errorf("output is already closed")
+	}
+
+	var err error
+	switch p.format {
+	case "json":
+		err = p.printJSON(row)
+	case "yaml":
+		err = p.printYAML(row)
+	case "table":
+		err = p.printTable(row)
+	}
+	if err != nil {
+		p.Close()
+		return err
+	}
+
+	p.rows++
+	return nil
+}
+
+// Close finishes the output. Without rows, JSON and YAML get an empty list so
+// that scripts can still parse them, and tables the Empty message. Calls after
+// the first do nothing, so Close can be deferred next to an explicit call.
+func (p *StreamPrinter) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	if p.rows == 0 {
+		switch p.format {
+		case "json", "yaml":
+			_, err := io.WriteString(p.out, "[]\n")
+			return err
+		}
+		if p.empty != "" {
+			PrintInfo("%s", p.empty)
+		}
+		return nil
+	}
+
+	switch p.format {
+	case "json":
+		_, err := io.WriteString(p.out, "\n]\n")
+		return err
+	case "table":
+		return p.table.Flush()
+	}
+	return nil
+}
+
+func (p *StreamPrinter) printJSON(row map[string]interface{}) error {
+	data, err := json.MarshalIndent(row, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n  "
+	if p.rows == 0 {
+		prefix = "[\n  "
+	}
+	_, err = fmt.Fprintf(p.out, "%s%s", prefix, data)
+	return err
+}
+
+func (p *StreamPrinter) printYAML(row map[string]interface{}) error {
+	data, err := yaml.Marshal([]map[string]interface{}{row})
+	if err != nil {
+		return err
+	}
+	_, err = p.out.Write(data)
+	return err
+}
+
+func (p *StreamPrinter) printTable(row map[string]interface{}) error {
+	if p.table == nil {
+		p.table = tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+
+		headers := make([]string, len(p.columns))
+		for i, column := range p.columns {
+			headers[i] = strings.ToUpper(column)
+		}
+		fmt.Fprintln(p.table, strings.Join(headers, "\t"))
+	}
+
+	values := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		if value, ok := row[column]; ok && value != nil {
+			values[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	fmt.Fprintln(p.table, strings.Join(values, "\t"))
+
+	if (p.rows+1)%streamFlushRows == 0 {
+		return p.table.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStreamPrinter(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"ID": "svc-1", "Name": "weather"},
+		{"ID": "svc-2", "Name": "news"},
+	}
+	// A channel cannot be encoded as JSON, so printing it fails
+	bad := map[string]interface{}{"ID": make(chan int)}
+
+	tests := []struct {
+		name   string
+		format string
+		rows   []map[string]interface{}
+		// fail prints a row that JSON cannot encode after rows
+		fail bool
+		want int
+	}{
+		{name: "json rows", format: "json", rows: rows, want: 2},
+		{name: "json without rows", format: "json", want: 0},
+		{name: "json failing first row", format: "json", fail: true, want: 0},
+		{name: "json failing after rows", format: "json", rows: rows, fail: true, want: 2},
+		{name: "yaml rows", format: "yaml", rows: rows, want: 2},
+		{name: "yaml without rows", format: "yaml", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printer, err := NewStreamPrinter(tt.format, "ID", "Name")
+			if err != nil {
+				t.Fatal(err)
+			}
+			printer.out = &out
+
+			for _, row := range tt.rows {
+				if err := printer.Print(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.fail {
+				if err := printer.Print(bad); err == nil {
+					t.Fatal("Print() of an unencodable row did not fail")
+				}
+				if err := printer.Print(rows[0]); err == nil {
+					t.Error("Print() after a failed row did not fail")
+				}
+			}
+			if err := printer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := printer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []map[string]interface{}
+			if tt.format == "json" {
+				err = json.Unmarshal(out.Bytes(), &got)
+			} else {
+				err = yaml.Unmarshal(out.Bytes(), &got)
+			}
+			if err != nil {
+				t.Fatalf("output does not parse: %v\n%s", err, out.String())
+			}
+			if got == nil || len(got) != tt.want {
+				t.Errorf("output has %d rows, want a list of %d:\n%s", len(got), tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestStreamPrinterTable(t *testing.T) {
+	var out bytes.Buffer
+	printer, err := NewStreamPrinter("table", "ID", "Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer.out = &out
+
+	if err := printer.Print(map[string]interface{}{"ID": "svc-1", "Name": "weather"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := printer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ID     NAME\nsvc-1  weather\n"
+	if out.String() != want {
+		t.Errorf("table output = %q, want %q", out.String(), want)
+	}
+}
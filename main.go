@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/Exmplr-AI/aphelion-cli/cmd"
+	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
+		var exitErr *utils.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
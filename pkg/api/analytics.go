@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"strconv"
+)
+
+// AnalyticsService reads usage analytics
+type AnalyticsService struct {
+	client *Client
+}
+
+func (c *Client) Analytics() *AnalyticsService {
+	return &AnalyticsService{client: c}
+}
+
+// User returns request, session and tool metrics for the current user
+func (s *AnalyticsService) User(ctx context.Context, opts AnalyticsOptions) (*Analytics, error) {
+	params := map[string]string{"timeframe": opts.Timeframe}
+	return s.get(ctx, "/analytics/user", params)
+}
+
+// Tools returns tool usage metrics, globally or for the current user only
+func (s *AnalyticsService) Tools(ctx context.Context, opts AnalyticsOptions) (*Analytics, error) {
+	return s.get(ctx, "/analytics/tools", scopedParams(opts))
+}
+
+// Sessions returns session metrics, globally or for the current user only
+func (s *AnalyticsService) Sessions(ctx context.Context, opts AnalyticsOptions) (*Analytics, error) {
+	return s.get(ctx, "/analytics/sessions", scopedParams(opts))
+}
+
+func scopedParams(opts AnalyticsOptions) map[string]string {
+	return map[string]string{
+		"timeframe": opts.Timeframe,
+		"user_only": strconv.FormatBool(opts.UserOnly),
+	}
+}
+
+func (s *AnalyticsService) get(ctx context.Context, endpoint string, params map[string]string) (*Analytics, error) {
+	var analytics Analytics
+	if err := s.client.GetWithQueryContext(ctx, endpoint, params, &analytics); err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// AuthService handles gateway authentication endpoints
+type AuthService struct {
+	client *Client
+}
+
+func (c *Client) Auth() *AuthService {
+	return &AuthService{client: c}
+}
+
+// Info returns the OAuth configuration used for browser login
+func (s *AuthService) Info(ctx context.Context) (*AuthInfo, error) {
+	var info AuthInfo
+	if err := s.client.GetContext(ctx, "/auth/info", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Login authenticates with username and password
+func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
+	var response AuthResponse
+	if err := s.client.PostContext(ctx, "/auth/login", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error) {
+	var response AuthResponse
+	if err := s.client.PostContext(ctx, "/auth/register", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Profile returns the authenticated user's profile
+func (s *AuthService) Profile(ctx context.Context) (*User, error) {
+	var user User
+	if err := s.client.GetContext(ctx, "/auth/profile", &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateToken issues a personal access token for the authenticated user
+func (s *AuthService) CreateToken(ctx context.Context, req CreateTokenRequest) (*CreateTokenResponse, error) {
+	var response CreateTokenResponse
+	if err := s.client.PostContext(ctx, "/auth/tokens", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListTokens returns the authenticated user's personal access tokens
+func (s *AuthService) ListTokens(ctx context.Context) ([]PersonalAccessToken, error) {
+	var response TokensResponse
+	if err := s.client.GetContext(ctx, "/auth/tokens", &response); err != nil {
+		return nil, err
+	}
+	return response.Tokens, nil
+}
+
+// RevokeToken revokes a personal access token by ID
+func (s *AuthService) RevokeToken(ctx context.Context, id string) error {
+	return s.client.DeleteContext(ctx, fmt.Sprintf("/auth/tokens/%s", url.PathEscape(id)))
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// skippedCassetteHeaders are not stored with recorded responses. The length
+// is left out because redaction can change the body.
+var skippedCassetteHeaders = map[string]bool{
+	"Content-Length": true,
+	"Date":           true,
+	"Set-Cookie":     true,
+}
+
+// Cassette holds recorded gateway interactions for offline replay
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	path string
+	mu   sync.Mutex
+	used map[int]bool
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Request    CassetteRequest  `json:"request"`
+	Response   CassetteResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+// CassetteRequest identifies a request. Bodies are stored as a hash only so
+// credentials never end up in cassette files; response bodies are redacted
+// the same way as in traces.
+type CassetteRequest struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Query      string `json:"query,omitempty"`
+	BodySHA256 string `json:"body_sha256,omitempty"`
+}
+
+// CassetteResponse is the recorded response served during replay
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+var (
+	cassettesMu sync.Mutex
+	cassettes   = map[string]*Cassette{}
+)
+
+// OpenCassette loads the cassette at path, or returns an empty one if the
+// file does not exist yet. Cassettes are shared per path within a process.
+func OpenCassette(path string) (*Cassette, error) {
+	cassettesMu.Lock()
+	defer cassettesMu.Unlock()
+
+	if c, ok := cassettes[path]; ok {
+		return c, nil
+	}
+
+	c := &Cassette{path: path, used: make(map[int]bool)}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+	}
+
+	cassettes[path] = c
+	return c, nil
+}
+
+// RecordMiddleware forwards requests to the network and appends each
+// request/response pair to the cassette, with tokens and secrets in the
+// response body masked
+func RecordMiddleware(c *Cassette) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			key, err := cassetteKey(req)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read response for recording: %w", err)
+			}
+
+			header := http.Header{}
+			for name, values := range resp.Header {
+				if !skippedCassetteHeaders[name] {
+					header[name] = values
+				}
+			}
+
+			if err := c.append(&Interaction{
+				Request: key,
+				Response: CassetteResponse{
+					StatusCode: resp.StatusCode,
+					Header:     header,
+					Body:       string(redactJSON(body)),
+				},
+				RecordedAt: time.Now().UTC(),
+			}); err != nil {
+				return nil, err
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+// ReplayMiddleware serves responses from the cassette without touching the
+// network. Requests match on method, path, query and body hash; matching
+// interactions are served in recorded order and the last one is reused once
+// all have been consumed.
+func ReplayMiddleware(c *Cassette) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			key, err := cassetteKey(req)
+			if err != nil {
+				return nil, err
+			}
+
+			interaction := c.match(key)
+			if interaction == nil {
+				target := key.Path
+				if key.Query != "" {
+					target += "?" + key.Query
+				}
+				return nil, fmt.Errorf("no recorded interaction in %s for %s %s", c.path, key.Method, target)
+			}
+
+			header := interaction.Response.Header.Clone()
+			if header == nil {
+				header = http.Header{}
+			}
+
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+				StatusCode:    interaction.Response.StatusCode,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        header,
+				Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+				ContentLength: int64(len(interaction.Response.Body)),
+				Request:       req,
+			}, nil
+		}
+	}
+}
+
+func (c *Cassette) match(key CassetteRequest) *Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, interaction := range c.Interactions {
+		if interaction.Request != key {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction
+		}
+		last = i
+	}
+
+	if last >= 0 {
+		return c.Interactions[last]
+	}
+	return nil
+}
+
+func (c *Cassette) append(interaction *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, interaction)
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette %s: %w", c.path, err)
+	}
+
+	return nil
+}
+
+// cassetteKey builds the matching key for a request. The host is ignored so
+// cassettes replay against any --api-url.
+func cassetteKey(req *http.Request) (CassetteRequest, error) {
+	key := CassetteRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return key, fmt.Errorf("failed to read request body: %w", err)
+		}
+		data, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return key, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(data) > 0 {
+			sum := sha256.Sum256(data)
+			key.BodySHA256 = hex.EncodeToString(sum[:])
+		}
+	}
+
+	return key, nil
+}
+
+// failingMiddleware rejects every request with err
+func failingMiddleware(err error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, err
+		}
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,12 +17,16 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      RetryPolicy
+	headers    map[string]string
+	middleware []Middleware
 }
 
 type APIError struct {
 	StatusCode int    `json:"status_code"`
 	Message    string `json:"message"`
 	ErrorMsg   string `json:"error"`
+	RequestID  string `json:"request_id,omitempty"`
 }
 
 func (e *APIError) Error() string {
@@ -38,127 +43,144 @@ func NewClient() *Client {
 	return &Client{
 		baseURL: config.GetAPIUrl(),
 		httpClient: &http.Client{
-			Timeout: time.Second * 30,
+			Timeout: config.GetTimeout(),
 		},
+		retry:   DefaultRetryPolicy(),
+		headers: make(map[string]string),
 	}
 }
 
-func (c *Client) buildURL(endpoint string) string {
-	baseURL := strings.TrimSuffix(c.baseURL, "/")
-	endpoint = strings.TrimPrefix(endpoint, "/")
-	return fmt.Sprintf("%s/%s", baseURL, endpoint)
+// SetTimeout overrides the per-request timeout used by the client
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
 }
 
-func (c *Client) request(method, endpoint string, body interface{}, headers map[string]string) (*http.Response, error) {
-	url := c.buildURL(endpoint)
-	
-	var reqBody io.Reader
-	if body != nil {
-		jsonData, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		reqBody = bytes.NewBuffer(jsonData)
-	}
+// SetRetryPolicy overrides the retry policy used by the client
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
 
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// SetHeader adds a header sent with every request made by the client
+func (c *Client) SetHeader(key, value string) {
+	c.headers[key] = value
+}
+
+// Use appends middleware to the request pipeline. Custom middleware runs
+// after the built-in request ID, user agent, header, retry and auth stages,
+// once per attempt, just before the request is sent (and traced).
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// handler assembles the request pipeline shared by every verb
+func (c *Client) handler() Handler {
+	middleware := []Middleware{
+		RequestIDMiddleware(),
+		UserAgentMiddleware(UserAgent),
+		HeadersMiddleware(c.headers),
+		RetryMiddleware(c.retry),
+		AuthMiddleware(),
+		LoggingMiddleware(c.retry.Verbose),
 	}
+	middleware = append(middleware, c.middleware...)
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if config.IsHTTPDebug() {
+		middleware = append(middleware, TraceMiddleware(config.GetTraceFile()))
 	}
 
-	if token := config.GetAccessToken(); token != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	if mw := cassetteMiddleware(); mw != nil {
+		middleware = append(middleware, mw)
 	}
 
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	return chain(c.httpClient.Do, middleware...)
+}
+
+// cassetteMiddleware returns the replay or record stage when enabled.
+// Replay takes precedence so recorded runs never hit the network.
+func cassetteMiddleware() Middleware {
+	path, replay := config.GetReplayPath(), true
+	if path == "" {
+		path, replay = config.GetRecordPath(), false
+	}
+	if path == "" {
+		return nil
 	}
 
-	resp, err := c.httpClient.Do(req)
+	cassette, err := OpenCassette(path)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return failingMiddleware(err)
 	}
 
-	if resp.StatusCode >= 400 {
-		defer resp.Body.Close()
-		
-		var apiErr APIError
-		apiErr.StatusCode = resp.StatusCode
-		
-		if respBody, err := io.ReadAll(resp.Body); err == nil {
-			if err := json.Unmarshal(respBody, &apiErr); err != nil {
-				apiErr.Message = string(respBody)
-			}
-		}
-		
-		return nil, &apiErr
+	if replay {
+		return ReplayMiddleware(cassette)
 	}
+	return RecordMiddleware(cassette)
+}
 
-	return resp, nil
+func (c *Client) buildURL(endpoint string) string {
+	baseURL := strings.TrimSuffix(c.baseURL, "/")
+	endpoint = strings.TrimPrefix(endpoint, "/")
+	return fmt.Sprintf("%s/%s", baseURL, endpoint)
 }
 
-func (c *Client) Get(endpoint string, result interface{}) error {
-	resp, err := c.request("GET", endpoint, nil, nil)
+// newRequest builds an HTTP request for the endpoint with optional query
+// parameters and JSON body
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, params map[string]string, body interface{}) (*http.Request, error) {
+	u, err := url.Parse(c.buildURL(endpoint))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+	if len(params) > 0 {
+		q := u.Query()
+		for key, value := range params {
+			q.Set(key, value)
 		}
+		u.RawQuery = q.Encode()
 	}
 
-	return nil
-}
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonData)
+	}
 
-func (c *Client) GetWithQuery(endpoint string, params map[string]string, result interface{}) error {
-	u, err := url.Parse(c.buildURL(endpoint))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	q := u.Query()
-	for key, value := range params {
-		q.Set(key, value)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	u.RawQuery = q.Encode()
+	req.Header.Set("Accept", "application/json")
 
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	return req, nil
+}
 
-	if token := config.GetAccessToken(); token != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+// do sends a request through the pipeline and decodes the JSON response into
+// result when it is non-nil
+func (c *Client) do(ctx context.Context, method, endpoint string, params map[string]string, body, result interface{}) error {
+	req, err := c.newRequest(ctx, method, endpoint, params, body)
+	if err != nil {
+		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.handler()(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		var apiErr APIError
-		apiErr.StatusCode = resp.StatusCode
-		
-		if respBody, err := io.ReadAll(resp.Body); err == nil {
-			if err := json.Unmarshal(respBody, &apiErr); err != nil {
-				apiErr.Message = string(respBody)
-			}
-		}
-		
-		return &apiErr
+		return decodeError(req, resp)
 	}
 
 	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
 			return fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
@@ -166,33 +188,77 @@ func (c *Client) GetWithQuery(endpoint string, params map[string]string, result
 	return nil
 }
 
-func (c *Client) Post(endpoint string, body interface{}, result interface{}) error {
-	resp, err := c.request("POST", endpoint, body, nil)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// decodeError converts an error response into an APIError
+func decodeError(req *http.Request, resp *http.Response) error {
+	var apiErr APIError
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+	if respBody, err := io.ReadAll(resp.Body); err == nil {
+		if err := json.Unmarshal(respBody, &apiErr); err != nil {
+			apiErr.Message = string(respBody)
 		}
 	}
 
-	return nil
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = req.Header.Get("X-Request-ID")
+	}
+
+	return &apiErr
+}
+
+func (c *Client) Get(endpoint string, result interface{}) error {
+	return c.GetContext(context.Background(), endpoint, result)
+}
+
+func (c *Client) GetContext(ctx context.Context, endpoint string, result interface{}) error {
+	return c.do(ctx, http.MethodGet, endpoint, nil, nil, result)
+}
+
+func (c *Client) GetWithQuery(endpoint string, params map[string]string, result interface{}) error {
+	return c.GetWithQueryContext(context.Background(), endpoint, params, result)
+}
+
+func (c *Client) GetWithQueryContext(ctx context.Context, endpoint string, params map[string]string, result interface{}) error {
+	return c.do(ctx, http.MethodGet, endpoint, params, nil, result)
+}
+
+func (c *Client) Post(endpoint string, body interface{}, result interface{}) error {
+	return c.PostContext(context.Background(), endpoint, body, result)
+}
+
+func (c *Client) PostContext(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.do(ctx, http.MethodPost, endpoint, nil, body, result)
+}
+
+func (c *Client) Put(endpoint string, body interface{}, result interface{}) error {
+	return c.PutContext(context.Background(), endpoint, body, result)
+}
+
+func (c *Client) PutContext(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.do(ctx, http.MethodPut, endpoint, nil, body, result)
+}
+
+func (c *Client) Patch(endpoint string, body interface{}, result interface{}) error {
+	return c.PatchContext(context.Background(), endpoint, body, result)
+}
+
+func (c *Client) PatchContext(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
+	return c.do(ctx, http.MethodPatch, endpoint, nil, body, result)
 }
 
 func (c *Client) Delete(endpoint string) error {
-	resp, err := c.request("DELETE", endpoint, nil, nil)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return c.DeleteContext(context.Background(), endpoint)
+}
 
-	return nil
+func (c *Client) DeleteContext(ctx context.Context, endpoint string) error {
+	return c.do(ctx, http.MethodDelete, endpoint, nil, nil, nil)
 }
 
 func (c *Client) HealthCheck() error {
+	return c.HealthCheckContext(context.Background())
+}
+
+func (c *Client) HealthCheckContext(ctx context.Context) error {
 	var result map[string]interface{}
-	return c.Get("/health", &result)
-}
\ No newline at end of file
+	return c.GetContext(ctx, "/health", &result)
+}
@@ -0,0 +1,320 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/internal/mockgateway"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/api"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/auth"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+// The integration tests run the client against the mock gateway with a
+// config file in a temporary home directory
+var (
+	gateway *mockgateway.Server
+	store   *mockgateway.Store
+	baseURL string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithGateway(m))
+}
+
+func runWithGateway(m *testing.M) int {
+	home, err := os.MkdirTemp("", "aphelion-api-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(home)
+
+	store, err = mockgateway.NewStore("", "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	gateway = mockgateway.NewServer(store, nil)
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+	baseURL = server.URL
+
+	os.Setenv("HOME", home)
+	os.Setenv("APHELION_API_URL", baseURL)
+	os.Setenv("APHELION_CREDENTIAL_STORE", config.CredentialStorePlaintext)
+	os.Setenv("APHELION_RETRIES", "0")
+	for _, name := range []string{config.TokenEnv, config.ClientIDEnv, config.ClientSecretEnv, "APHELION_PROFILE", "APHELION_ACCOUNT"} {
+		os.Unsetenv(name)
+	}
+	config.InitConfig()
+
+	return m.Run()
+}
+
+func TestPasswordLogin(t *testing.T) {
+	ctx := context.Background()
+
+	resp, err := api.NewClient().Auth().Login(ctx, api.LoginRequest{Username: "alice", Password: "secret"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if resp.Token == "" || resp.User.Username != "alice" {
+		t.Fatalf("login returned %+v", resp)
+	}
+	if err := config.SetAuth(resp.Token, resp.User.ID, resp.User.Email, resp.User.Username); err != nil {
+		t.Fatalf("saving the login failed: %v", err)
+	}
+
+	if got := config.GetAccessToken(); got != resp.Token {
+		t.Errorf("stored access token = %q, want %q", got, resp.Token)
+	}
+	path, err := config.ConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the login was not saved to %s: %v", filepath.Base(path), err)
+	}
+
+	user, err := api.NewClient().Auth().Profile(ctx)
+	if err != nil {
+		t.Fatalf("profile with the new token failed: %v", err)
+	}
+	if user.Username != "alice" {
+		t.Errorf("profile user = %q, want alice", user.Username)
+	}
+}
+
+func TestOAuthLogin(t *testing.T) {
+	ctx := context.Background()
+
+	info, err := api.NewClient().Auth().Info(ctx)
+	if err != nil {
+		t.Fatalf("auth info failed: %v", err)
+	}
+	oauthConfig := &auth.OAuthConfig{ClientID: info.ClientID, AuthURL: info.AuthURL, RedirectURI: auth.CallbackURL(auth.DefaultCallbackPort)}
+	if err := auth.PrepareAuthorization(oauthConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mock issues codes of the form mock-code-<user>
+	tokens, err := auth.ExchangeCodeForToken(ctx, oauthConfig, "mock-code-bob")
+	if err != nil {
+		t.Fatalf("code exchange failed: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("code exchange returned %+v", tokens)
+	}
+
+	claims, err := auth.DecodeJWT(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("access token is not a JWT: %v", err)
+	}
+	if claims.Subject != "bob" {
+		t.Errorf("token subject = %q, want bob", claims.Subject)
+	}
+
+	err = config.SetAuthTokens(config.TokenSet{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Expiry:       tokens.Expiry(),
+		TokenURL:     auth.TokenURL(oauthConfig),
+		ClientID:     oauthConfig.ClientID,
+	}, "bob", "bob@mock.aphelion.local", "bob")
+	if err != nil {
+		t.Fatalf("saving the login failed: %v", err)
+	}
+
+	user, err := api.NewClient().Auth().Profile(ctx)
+	if err != nil {
+		t.Fatalf("profile with the new token failed: %v", err)
+	}
+	if user.ID != "bob" {
+		t.Errorf("profile user = %q, want bob", user.ID)
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	ctx := context.Background()
+	tokenURL := baseURL + "/oauth/token"
+
+	tests := []struct {
+		name string
+		// ttl is the lifetime of tokens issued by the gateway
+		ttl time.Duration
+		// login returns the tokens to start with
+		login func(t *testing.T) config.TokenSet
+		// wait lets the access token expire before the request
+		wait time.Duration
+	}{
+		{
+			name: "token about to expire is renewed before the request",
+			ttl:  time.Hour,
+			login: func(t *testing.T) config.TokenSet {
+				tokens, err := auth.RefreshAccessToken(ctx, tokenURL, "mock-client", "mock-refresh-carol.1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return config.TokenSet{
+					AccessToken:  tokens.AccessToken,
+					RefreshToken: tokens.RefreshToken,
+					Expiry:       time.Now().Add(10 * time.Second),
+					TokenURL:     tokenURL,
+					ClientID:     "mock-client",
+				}
+			},
+		},
+		{
+			name: "rejected token is renewed and the request retried",
+			ttl:  time.Second,
+			login: func(t *testing.T) config.TokenSet {
+				tokens, err := auth.RefreshAccessToken(ctx, tokenURL, "mock-client", "mock-refresh-carol.2")
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Without a known expiry only the gateway's 401 tells
+				return config.TokenSet{
+					AccessToken:  tokens.AccessToken,
+					RefreshToken: tokens.RefreshToken,
+					TokenURL:     tokenURL,
+					ClientID:     "mock-client",
+				}
+			},
+			wait: 2 * time.Second,
+		},
+		{
+			name: "service account requests a new token with its client secret",
+			ttl:  time.Hour,
+			login: func(t *testing.T) config.TokenSet {
+				tokens, err := auth.ClientCredentialsToken(ctx, tokenURL, "nightly-agent", "mock-secret", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return config.TokenSet{
+					AccessToken:  tokens.AccessToken,
+					Expiry:       time.Now().Add(-time.Minute),
+					TokenURL:     tokenURL,
+					ClientID:     "nightly-agent",
+					ClientSecret: "mock-secret",
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway.SetTokenTTL(tt.ttl)
+			defer gateway.SetTokenTTL(0)
+
+			tokens := tt.login(t)
+			if err := config.SetAuthTokens(tokens, "", "", "carol"); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tt.wait)
+
+			if _, err := api.NewClient().Auth().Profile(ctx); err != nil {
+				t.Fatalf("request with a stale token failed: %v", err)
+			}
+
+			renewed := config.GetTokenSet()
+			if renewed.AccessToken == tokens.AccessToken {
+				t.Fatal("the access token was not renewed")
+			}
+			if !renewed.Expiry.After(time.Now()) {
+				t.Errorf("renewed token expiry = %v, want a time in the future", renewed.Expiry)
+			}
+			if tokens.RefreshToken != "" && renewed.RefreshToken == tokens.RefreshToken {
+				t.Errorf("the rotated refresh token was not saved")
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+
+	// Services and memories are owned by the user of the token
+	if err := config.SetAuth("mock-token-dave", "dave", "dave@mock.aphelion.local", "dave"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 23; i++ {
+		store.CreateService("dave", map[string]interface{}{"name": fmt.Sprintf("service-%02d", i)})
+	}
+	for i := 0; i < 12; i++ {
+		store.SaveMemory("dave", api.Memory{Summary: fmt.Sprintf("memory %d", i)})
+	}
+
+	client := api.NewClient()
+	tests := []struct {
+		name      string
+		pager     func() (next func() bool, id func() string, err func() error)
+		wantCount int
+	}{
+		{
+			name: "public services",
+			pager: func() (func() bool, func() string, func() error) {
+				p := client.Services().ListPager(5)
+				return func() bool { return p.Next(ctx) }, func() string { return p.Item().ID }, p.Err
+			},
+			wantCount: 23,
+		},
+		{
+			name: "owned services with max items",
+			pager: func() (func() bool, func() string, func() error) {
+				p := client.Services().ListOwnedPager(10).SetMaxItems(15)
+				return func() bool { return p.Next(ctx) }, func() string { return p.Item().ID }, p.Err
+			},
+			wantCount: 15,
+		},
+		{
+			name: "services in one page",
+			pager: func() (func() bool, func() string, func() error) {
+				p := client.Services().ListPager(100)
+				return func() bool { return p.Next(ctx) }, func() string { return p.Item().ID }, p.Err
+			},
+			wantCount: 23,
+		},
+		{
+			name: "memories by cursor",
+			pager: func() (func() bool, func() string, func() error) {
+				p := client.Memory().ListPager(api.MemoryListOptions{Limit: 5})
+				return func() bool { return p.Next(ctx) }, func() string { return p.Item().ID }, p.Err
+			},
+			wantCount: 12,
+		},
+		{
+			name: "memories oldest first with a page size dividing the total",
+			pager: func() (func() bool, func() string, func() error) {
+				p := client.Memory().ListPager(api.MemoryListOptions{Limit: 4, Sort: "oldest"})
+				return func() bool { return p.Next(ctx) }, func() string { return p.Item().ID }, p.Err
+			},
+			wantCount: 12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, id, pagerErr := tt.pager()
+
+			seen := map[string]bool{}
+			for next() {
+				if seen[id()] {
+					t.Fatalf("item %s was returned twice", id())
+				}
+				seen[id()] = true
+			}
+			if err := pagerErr(); err != nil {
+				t.Fatalf("pagination failed: %v", err)
+			}
+			if len(seen) != tt.wantCount {
+				t.Errorf("got %d items, want %d", len(seen), tt.wantCount)
+			}
+		})
+	}
+}
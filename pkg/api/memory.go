@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// MemoryService manages agent memories
+type MemoryService struct {
+	client *Client
+}
+
+func (c *Client) Memory() *MemoryService {
+	return &MemoryService{client: c}
+}
+
+// List returns the most recent memories
+func (s *MemoryService) List(ctx context.Context) (*MemoriesResponse, error) {
+	var response MemoriesResponse
+	if err := s.client.GetContext(ctx, "/memory", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListPage returns one page of memories using the paginated endpoint
+func (s *MemoryService) ListPage(ctx context.Context, opts MemoryListOptions) (*MemoriesResponse, error) {
+	params := map[string]string{}
+	if opts.Limit > 0 {
+		params["limit"] = strconv.Itoa(opts.Limit)
+	}
+	if opts.Sort != "" {
+		params["sort"] = opts.Sort
+	}
+	if opts.DateFrom != "" {
+		params["date_from"] = opts.DateFrom
+	}
+	if opts.DateTo != "" {
+		params["date_to"] = opts.DateTo
+	}
+	if opts.Cursor != "" {
+		params["cursor"] = opts.Cursor
+	}
+
+	var response MemoriesResponse
+	if err := s.client.GetWithQueryContext(ctx, "/memory/paginated", params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListPager walks all memories matching opts, opts.Limit at a time,
+// following the cursor returned with each page
+func (s *MemoryService) ListPager(opts MemoryListOptions) *Pager[Memory] {
+	return NewPager(opts.Limit, func(ctx context.Context, token string, size int) ([]Memory, string, error) {
+		page := opts
+		page.Limit = size
+		page.Cursor = token
+		if token == "" {
+			page.Cursor = opts.Cursor
+		}
+
+		response, err := s.ListPage(ctx, page)
+		if err != nil {
+			return nil, "", err
+		}
+		return response.Memories, response.Cursor, nil
+	})
+}
+
+// Search finds memories by semantic similarity
+func (s *MemoryService) Search(ctx context.Context, opts MemorySearchOptions) ([]Memory, error) {
+	params := map[string]string{
+		"q":         opts.Query,
+		"threshold": fmt.Sprintf("%.2f", opts.Threshold),
+	}
+	if opts.Limit > 0 {
+		params["limit"] = strconv.Itoa(opts.Limit)
+	}
+
+	var memories []Memory
+	if err := s.client.GetWithQueryContext(ctx, "/memory/search", params, &memories); err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+func (s *MemoryService) Save(ctx context.Context, req SaveMemoryRequest) (*Memory, error) {
+	var memory Memory
+	if err := s.client.PostContext(ctx, "/memory", req, &memory); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+func (s *MemoryService) Stats(ctx context.Context) (*MemoryStats, error) {
+	var stats MemoryStats
+	if err := s.client.GetContext(ctx, "/memory/stats", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Clear deletes all memories, or only those of sessionID when it is non-empty
+func (s *MemoryService) Clear(ctx context.Context, sessionID string) error {
+	endpoint := "/memory"
+	if sessionID != "" {
+		endpoint = "/memory?session_id=" + url.QueryEscape(sessionID)
+	}
+	return s.client.DeleteContext(ctx, endpoint)
+}
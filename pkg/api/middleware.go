@@ -0,0 +1,143 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+// UserAgent is sent with every gateway request. The CLI sets it from its
+// build version at startup.
+var UserAgent = fmt.Sprintf("aphelion-cli/dev (%s/%s)", runtime.GOOS, runtime.GOARCH)
+
+// Handler sends a prepared request and returns the raw response
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler with additional behavior
+type Middleware func(next Handler) Handler
+
+// chain wraps h with the given middleware; the first middleware is the outermost
+func chain(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// RequestIDMiddleware tags each request with a unique X-Request-ID header so
+// calls can be correlated with gateway logs. Retries reuse the same ID.
+func RequestIDMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Request-ID") == "" {
+				req.Header.Set("X-Request-ID", newRequestID())
+			}
+			return next(req)
+		}
+	}
+}
+
+// UserAgentMiddleware sets the User-Agent header
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", userAgent)
+			return next(req)
+		}
+	}
+}
+
+// HeadersMiddleware sets static headers on every request
+func HeadersMiddleware(headers map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			for key, value := range headers {
+				req.Header.Set(key, value)
+			}
+			return next(req)
+		}
+	}
+}
+
+// AuthMiddleware injects the stored bearer token unless the request already
+// carries an Authorization header. OAuth tokens are refreshed shortly before
+// they expire, and once more if the gateway rejects them with a 401, in which
+// case the request is retried with the new token.
+func AuthMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "" {
+				return next(req)
+			}
+
+			tokens := config.GetTokenSet()
+			token := tokens.AccessToken
+			if token == "" {
+				return next(req)
+			}
+
+			// Replayed responses do not depend on the token, and refreshing
+			// it would reach the network
+			canRefresh := tokens.CanRefresh() && config.GetReplayPath() == ""
+
+			refreshed := false
+			if canRefresh && tokens.ExpiresWithin(refreshSkew) {
+				refreshed = true
+				if fresh, err := refreshAccessToken(req.Context(), token); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				} else {
+					token = fresh
+				}
+			}
+
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			resp, err := next(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || refreshed || !canRefresh {
+				return resp, err
+			}
+
+			fresh, refreshErr := refreshAccessToken(req.Context(), token)
+			if refreshErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; run 'aphelion auth login' to sign in again\n", refreshErr)
+				return resp, err
+			}
+			return retryWithToken(next, req, resp, fresh)
+		}
+	}
+}
+
+// LoggingMiddleware prints a summary line for every attempt to stderr when
+// verbose output is enabled
+func LoggingMiddleware(verbose bool) Middleware {
+	return func(next Handler) Handler {
+		if !verbose {
+			return next
+		}
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "→ %s %s failed after %s: %v\n", req.Method, req.URL, elapsed, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "→ %s %s %d (%s)\n", req.Method, req.URL, resp.StatusCode, elapsed)
+			}
+			return resp, err
+		}
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
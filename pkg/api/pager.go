@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// PageFetcher fetches up to size items starting at token, which is empty for
+// the first page. It returns the items and the token of the next page, or an
+// empty token when there are no more pages.
+type PageFetcher[T any] func(ctx context.Context, token string, size int) ([]T, string, error)
+
+// Pager lazily walks a paginated endpoint one item at a time, fetching the
+// next page only when the current one is exhausted:
+//
+//	pager := client.Memory().ListPager(api.MemoryListOptions{Limit: 50})
+//	for pager.Next(ctx) {
+//		memory := pager.Item()
+//	}
+//	if err := pager.Err(); err != nil {
+//		return err
+//	}
+type Pager[T any] struct {
+	fetch    PageFetcher[T]
+	pageSize int
+	maxItems int
+
+	items []T
+	index int
+	token string
+	count int
+	done  bool
+	err   error
+}
+
+// NewPager returns a pager that requests pageSize items per page
+func NewPager[T any](pageSize int, fetch PageFetcher[T]) *Pager[T] {
+	return &Pager[T]{
+		fetch:    fetch,
+		pageSize: pageSize,
+		index:    -1,
+	}
+}
+
+// SetMaxItems stops the pager after n items. Zero means no limit.
+func (p *Pager[T]) SetMaxItems(n int) *Pager[T] {
+	p.maxItems = n
+	return p
+}
+
+// Next advances to the next item, fetching a new page when needed. It
+// returns false when all items have been read or an error occurred.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.err != nil || (p.maxItems > 0 && p.count >= p.maxItems) {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.items) {
+		if p.done {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+
+		size := p.pageSize
+		if p.maxItems > 0 && (size <= 0 || p.maxItems-p.count < size) {
+			size = p.maxItems - p.count
+		}
+
+		items, next, err := p.fetch(ctx, p.token, size)
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		// An endpoint that ignores the page token sends the same page again,
+		// and following it would loop forever. The listing is complete once
+		// a page adds nothing new; the first items are enough to tell.
+		if len(items) == 0 || (len(p.items) > 0 && reflect.DeepEqual(items[0], p.items[0])) {
+			p.done = true
+			return false
+		}
+		if next != "" && next == p.token {
+			p.err = fmt.Errorf("pagination made no progress after %d items: the next page token %q did not advance", p.count, next)
+			return false
+		}
+		p.done = next == ""
+		p.items, p.index, p.token = items, 0, next
+	}
+
+	p.count++
+	return true
+}
+
+// Item returns the current item. It is only valid after Next returned true.
+func (p *Pager[T]) Item() T {
+	return p.items[p.index]
+}
+
+// Err returns the error that stopped the pager, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// nextOffset returns the token for the page after one starting at offset
+// that returned count items of the requested size. Total is used when the
+// endpoint reports it; otherwise a short page marks the end, and so does a
+// page larger than requested, as the endpoint ignored the limit and sent
+// the whole listing.
+func nextOffset(offset, count, size, total int) string {
+	next := offset + count
+	if count == 0 || (total > 0 && next >= total) || (total <= 0 && size > 0 && count != size) {
+		return ""
+	}
+	return strconv.Itoa(next)
+}
+
+// parseOffset converts an offset token back to an integer
+func parseOffset(token string) int {
+	offset, err := strconv.Atoi(token)
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
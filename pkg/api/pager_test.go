@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNextOffset(t *testing.T) {
+	tests := []struct {
+		name                       string
+		offset, count, size, total int
+		want                       string
+	}{
+		{name: "full page with total", offset: 0, count: 10, size: 10, total: 25, want: "10"},
+		{name: "last page with total", offset: 20, count: 5, size: 10, total: 25, want: ""},
+		{name: "page reaching total exactly", offset: 10, count: 10, size: 10, total: 20, want: ""},
+		{name: "short page without total", offset: 0, count: 3, size: 10, total: 0, want: ""},
+		{name: "full page without total", offset: 30, count: 10, size: 10, total: 0, want: "40"},
+		{name: "empty page", offset: 10, count: 0, size: 10, total: 25, want: ""},
+		{name: "unlimited page size without total", offset: 0, count: 50, size: 0, total: 0, want: "50"},
+		{name: "short page with total left", offset: 0, count: 3, size: 10, total: 25, want: "3"},
+		{name: "page larger than requested without total", offset: 0, count: 23, size: 10, total: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextOffset(tt.offset, tt.count, tt.size, tt.total); got != tt.want {
+				t.Errorf("nextOffset(%d, %d, %d, %d) = %q, want %q", tt.offset, tt.count, tt.size, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := map[string]int{"": 0, "0": 0, "42": 42, "-3": 0, "abc": 0}
+	for token, want := range tests {
+		if got := parseOffset(token); got != want {
+			t.Errorf("parseOffset(%q) = %d, want %d", token, got, want)
+		}
+	}
+}
+
+// offsetFetcher pages through items with offset tokens, recording the
+// requested page sizes
+func offsetFetcher(items []int, sizes *[]int) PageFetcher[int] {
+	return func(ctx context.Context, token string, size int) ([]int, string, error) {
+		*sizes = append(*sizes, size)
+		offset := parseOffset(token)
+		end := offset + size
+		if size <= 0 || end > len(items) {
+			end = len(items)
+		}
+		page := items[offset:end]
+		return page, nextOffset(offset, len(page), size, len(items)), nil
+	}
+}
+
+func TestPager(t *testing.T) {
+	items := make([]int, 23)
+	for i := range items {
+		items[i] = i
+	}
+
+	tests := []struct {
+		name      string
+		pageSize  int
+		maxItems  int
+		wantCount int
+		wantSizes []int
+	}{
+		{name: "all pages", pageSize: 10, wantCount: 23, wantSizes: []int{10, 10, 10}},
+		{name: "single page", pageSize: 50, wantCount: 23, wantSizes: []int{50}},
+		{name: "max items within first page", pageSize: 10, maxItems: 4, wantCount: 4, wantSizes: []int{4}},
+		{name: "max items shrinks last page", pageSize: 10, maxItems: 15, wantCount: 15, wantSizes: []int{10, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			pager := NewPager(tt.pageSize, offsetFetcher(items, &sizes)).SetMaxItems(tt.maxItems)
+
+			var got []int
+			for pager.Next(context.Background()) {
+				got = append(got, pager.Item())
+			}
+			if err := pager.Err(); err != nil {
+				t.Fatalf("pager failed: %v", err)
+			}
+
+			if len(got) != tt.wantCount {
+				t.Fatalf("got %d items, want %d", len(got), tt.wantCount)
+			}
+			for i, item := range got {
+				if item != i {
+					t.Fatalf("item %d = %d, want %d", i, item, i)
+				}
+			}
+			if len(sizes) != len(tt.wantSizes) {
+				t.Fatalf("requested page sizes %v, want %v", sizes, tt.wantSizes)
+			}
+			for i := range sizes {
+				if sizes[i] != tt.wantSizes[i] {
+					t.Fatalf("requested page sizes %v, want %v", sizes, tt.wantSizes)
+				}
+			}
+		})
+	}
+}
+
+func TestPagerStopsWithoutProgress(t *testing.T) {
+	all := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name      string
+		fetch     PageFetcher[int]
+		wantItems int
+		wantErr   string
+	}{
+		{
+			name: "empty page with more to follow",
+			fetch: func(ctx context.Context, token string, size int) ([]int, string, error) {
+				return nil, "next", nil
+			},
+		},
+		{
+			name: "limit and offset ignored without a total",
+			fetch: func(ctx context.Context, token string, size int) ([]int, string, error) {
+				offset := parseOffset(token)
+				return all, nextOffset(offset, len(all), size, 0), nil
+			},
+			wantItems: len(all),
+		},
+		{
+			name: "offset ignored without a total",
+			fetch: func(ctx context.Context, token string, size int) ([]int, string, error) {
+				offset := parseOffset(token)
+				return all[:size], nextOffset(offset, size, size, 0), nil
+			},
+			wantItems: 2,
+		},
+		{
+			name: "token that does not advance",
+			fetch: func(ctx context.Context, token string, size int) ([]int, string, error) {
+				return []int{len(token)}, "same", nil
+			},
+			wantItems: 1,
+			wantErr:   "did not advance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pager := NewPager(2, tt.fetch)
+
+			n := 0
+			for pager.Next(context.Background()) {
+				if n++; n > 10 {
+					t.Fatal("pager did not stop")
+				}
+			}
+			if n != tt.wantItems {
+				t.Errorf("got %d items, want %d", n, tt.wantItems)
+			}
+			err := pager.Err()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Err() = %v, want none", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Err() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPagerFetchError(t *testing.T) {
+	want := errors.New("gateway unavailable")
+	pager := NewPager(10, func(ctx context.Context, token string, size int) ([]int, string, error) {
+		return nil, "", want
+	})
+
+	if pager.Next(context.Background()) {
+		t.Fatal("Next() = true after a failed fetch")
+	}
+	if err := pager.Err(); !errors.Is(err, want) {
+		t.Errorf("Err() = %v, want %v", err, want)
+	}
+}
+
+func TestPagerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pager := NewPager(10, func(ctx context.Context, token string, size int) ([]int, string, error) {
+		t.Fatal("fetched a page after the context was cancelled")
+		return nil, "", nil
+	})
+	if pager.Next(ctx) {
+		t.Fatal("Next() = true with a cancelled context")
+	}
+	if err := pager.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/auth"
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+// refreshSkew renews access tokens this long before they expire, so requests
+// are not sent with a token that runs out in flight
+const refreshSkew = 30 * time.Second
+
+// refreshMu serializes refreshes so concurrent requests share one new token
+// instead of each spending the refresh token
+var refreshMu sync.Mutex
+
+// refreshAccessToken exchanges the stored refresh token for a new access
+// token, or requests one with the client secret of a service account, and
+// saves the result. stale is the token the caller was about to use; if
+// another request already replaced it, the current token is returned as is.
+func refreshAccessToken(ctx context.Context, stale string) (string, error) {
+	if path := config.GetReplayPath(); path != "" {
+		return "", fmt.Errorf("failed to refresh access token: requests are replayed from %s", path)
+	}
+
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	tokens := config.GetTokenSet()
+	if tokens.AccessToken != stale && tokens.AccessToken != "" && !tokens.ExpiresWithin(refreshSkew) {
+		return tokens.AccessToken, nil
+	}
+	if !tokens.CanRefresh() {
+		return "", fmt.Errorf("failed to refresh access token: no refresh token available")
+	}
+
+	var resp *auth.TokenResponse
+	var err error
+	if tokens.IsClientCredentials() {
+		resp, err = auth.ClientCredentialsToken(ctx, tokens.TokenURL, tokens.ClientID, tokens.ClientSecret, tokens.Audience)
+	} else {
+		resp, err = auth.RefreshAccessToken(ctx, tokens.TokenURL, tokens.ClientID, tokens.RefreshToken)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tokens.AccessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		tokens.RefreshToken = resp.RefreshToken
+	}
+	tokens.Expiry = resp.Expiry()
+
+	if err := config.UpdateTokens(tokens); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save refreshed access token: %v\n", err)
+	}
+	return tokens.AccessToken, nil
+}
+
+// AccessToken returns the access token for handing to another process, such
+// as an agent, renewing it first when it expires within validFor
+func AccessToken(ctx context.Context, validFor time.Duration) (string, error) {
+	tokens := config.GetTokenSet()
+	if tokens.AccessToken == "" || !tokens.CanRefresh() || !tokens.ExpiresWithin(validFor) || config.GetReplayPath() != "" {
+		return tokens.AccessToken, nil
+	}
+	return refreshAccessToken(ctx, tokens.AccessToken)
+}
+
+// retryWithToken resends req with a new bearer token after the gateway
+// rejected it. Requests whose body cannot be replayed are not resent.
+func retryWithToken(next Handler, req *http.Request, resp *http.Response, token string) (*http.Response, error) {
+	retryReq := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retryReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return next(retryReq)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Exmplr-AI/aphelion-cli/pkg/config"
+)
+
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// RetryPolicy controls how transient gateway failures are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first one
+	MaxRetries int
+	// BaseDelay is the initial backoff delay, doubled on every attempt
+	BaseDelay time.Duration
+	// MaxWait caps a single backoff delay, including Retry-After values
+	MaxWait time.Duration
+	// RetryNonIdempotent allows retrying POST and PATCH requests
+	RetryNonIdempotent bool
+	// Verbose prints a trace line for each retried attempt
+	Verbose bool
+}
+
+// DefaultRetryPolicy builds a retry policy from the CLI configuration
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:         config.GetRetries(),
+		BaseDelay:          defaultRetryBaseDelay,
+		MaxWait:            config.GetRetryMaxWait(),
+		RetryNonIdempotent: config.GetRetryNonIdempotent(),
+		Verbose:            config.IsVerbose(),
+	}
+}
+
+// isIdempotent reports whether a request with the given method is safe to repeat
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryableStatus reports whether the status code indicates a transient failure
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// allows reports whether the policy permits retrying the given method
+func (p RetryPolicy) allows(method string) bool {
+	if p.MaxRetries <= 0 {
+		return false
+	}
+	return p.RetryNonIdempotent || isIdempotent(method)
+}
+
+// backoff returns the delay before the given retry attempt (1-based) using
+// exponential backoff with full jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	delay := base << uint(attempt-1)
+	if delay <= 0 || (p.MaxWait > 0 && delay > p.MaxWait) {
+		delay = p.MaxWait
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// wait returns the delay before the next attempt, preferring the server's
+// Retry-After header when present
+func (p RetryPolicy) wait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxWait > 0 && delay > p.MaxWait {
+				return p.MaxWait
+			}
+			return delay
+		}
+	}
+	return p.backoff(attempt)
+}
+
+// trace prints a retry trace line to stderr when verbose output is enabled
+func (p RetryPolicy) trace(method, url string, attempt int, reason string, delay time.Duration) {
+	if !p.Verbose {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "↻ %s %s attempt %d/%d failed (%s), retrying in %s\n",
+		method, url, attempt, p.MaxRetries+1, reason, delay.Round(time.Millisecond))
+}
+
+// RetryMiddleware retries transient failures according to the policy. Request
+// bodies are replayed through req.GetBody, so requests created with
+// http.NewRequest from a byte buffer can be retried safely.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if !policy.allows(req.Method) {
+				return next(req)
+			}
+
+			ctx := req.Context()
+			for attempt := 1; ; attempt++ {
+				attemptReq := req.Clone(ctx)
+				if attempt > 1 && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq.Body = body
+				}
+
+				resp, err := next(attemptReq)
+				if attempt > policy.MaxRetries || ctx.Err() != nil {
+					return resp, err
+				}
+
+				var reason string
+				switch {
+				case err != nil:
+					reason = err.Error()
+				case isRetryableStatus(resp.StatusCode):
+					reason = resp.Status
+				default:
+					return resp, nil
+				}
+
+				delay := policy.wait(attempt, resp)
+				if resp != nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				policy.trace(req.Method, req.URL.String(), attempt, reason, delay)
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "empty", value: "", wantOK: false},
+		{name: "seconds", value: "120", want: 2 * time.Minute, wantOK: true},
+		{name: "zero seconds", value: "0", want: 0, wantOK: true},
+		{name: "negative seconds", value: "-5", wantOK: false},
+		{name: "fractional seconds", value: "1.5", wantOK: false},
+		{name: "date in the past", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+		{name: "garbage", value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterFutureDate(t *testing.T) {
+	value := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	got, ok := parseRetryAfter(value)
+	if !ok {
+		t.Fatalf("parseRetryAfter(%q) was not accepted", value)
+	}
+	// The header has a resolution of one second
+	if got <= 58*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want about a minute", value, got)
+	}
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		retryAfter string
+		attempt    int
+		min, max   time.Duration
+	}{
+		{
+			name:       "retry after is honoured",
+			policy:     RetryPolicy{BaseDelay: time.Second, MaxWait: time.Minute},
+			retryAfter: "7",
+			attempt:    1,
+			min:        7 * time.Second,
+			max:        7 * time.Second,
+		},
+		{
+			name:       "retry after is capped by max wait",
+			policy:     RetryPolicy{BaseDelay: time.Second, MaxWait: 10 * time.Second},
+			retryAfter: "3600",
+			attempt:    1,
+			min:        10 * time.Second,
+			max:        10 * time.Second,
+		},
+		{
+			name:    "first backoff stays below the base delay",
+			policy:  RetryPolicy{BaseDelay: time.Second, MaxWait: time.Minute},
+			attempt: 1,
+			min:     1,
+			max:     time.Second,
+		},
+		{
+			name:    "backoff doubles per attempt",
+			policy:  RetryPolicy{BaseDelay: time.Second, MaxWait: time.Minute},
+			attempt: 4,
+			min:     1,
+			max:     8 * time.Second,
+		},
+		{
+			name:    "backoff is capped by max wait",
+			policy:  RetryPolicy{BaseDelay: time.Second, MaxWait: 5 * time.Second},
+			attempt: 30,
+			min:     1,
+			max:     5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			for i := 0; i < 100; i++ {
+				got := tt.policy.wait(tt.attempt, resp)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("wait(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		method string
+		want   bool
+	}{
+		{name: "idempotent", policy: RetryPolicy{MaxRetries: 3}, method: http.MethodGet, want: true},
+		{name: "non-idempotent", policy: RetryPolicy{MaxRetries: 3}, method: http.MethodPost, want: false},
+		{name: "non-idempotent when enabled", policy: RetryPolicy{MaxRetries: 3, RetryNonIdempotent: true}, method: http.MethodPost, want: true},
+		{name: "retries disabled", policy: RetryPolicy{}, method: http.MethodGet, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.allows(tt.method); got != tt.want {
+				t.Errorf("allows(%s) = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+}
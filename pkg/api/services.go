@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ServicesService accesses the service registry
+type ServicesService struct {
+	client *Client
+}
+
+func (c *Client) Services() *ServicesService {
+	return &ServicesService{client: c}
+}
+
+// List returns the public services in the registry
+func (s *ServicesService) List(ctx context.Context) (*ServicesResponse, error) {
+	var response ServicesResponse
+	if err := s.client.GetContext(ctx, "/services", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *ServicesService) Get(ctx context.Context, id string) (*Service, error) {
+	var service Service
+	if err := s.client.GetContext(ctx, fmt.Sprintf("/services/%s", url.PathEscape(id)), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// Manifest returns the STELLA manifest used for tool discovery
+func (s *ServicesService) Manifest(ctx context.Context, id string) (*STELLAManifest, error) {
+	var manifest STELLAManifest
+	if err := s.client.GetContext(ctx, fmt.Sprintf("/services/%s/manifest", url.PathEscape(id)), &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ListOwned returns the services registered by the current user
+func (s *ServicesService) ListOwned(ctx context.Context) (*ServicesResponse, error) {
+	var response ServicesResponse
+	if err := s.client.GetContext(ctx, "/owner/services", &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListPager walks all public services, pageSize at a time
+func (s *ServicesService) ListPager(pageSize int) *Pager[Service] {
+	return s.pager("/services", pageSize)
+}
+
+// ListOwnedPager walks all services registered by the current user,
+// pageSize at a time
+func (s *ServicesService) ListOwnedPager(pageSize int) *Pager[Service] {
+	return s.pager("/owner/services", pageSize)
+}
+
+// pager pages through a limit/offset service listing
+func (s *ServicesService) pager(endpoint string, pageSize int) *Pager[Service] {
+	return NewPager(pageSize, func(ctx context.Context, token string, size int) ([]Service, string, error) {
+		offset := parseOffset(token)
+		params := map[string]string{"offset": strconv.Itoa(offset)}
+		if size > 0 {
+			params["limit"] = strconv.Itoa(size)
+		}
+
+		var response ServicesResponse
+		if err := s.client.GetWithQueryContext(ctx, endpoint, params, &response); err != nil {
+			return nil, "", err
+		}
+		return response.Services, nextOffset(offset, len(response.Services), size, response.Total), nil
+	})
+}
+
+// Create registers a service from a name, description and optional OpenAPI spec
+func (s *ServicesService) Create(ctx context.Context, req CreateServiceRequest) (*Service, error) {
+	var response CreateServiceResponse
+	if err := s.client.PostContext(ctx, "/owner/services", req, &response); err != nil {
+		return nil, err
+	}
+	return &response.Service, nil
+}
+
+// Register registers a service from a STELLA manifest
+func (s *ServicesService) Register(ctx context.Context, manifest STELLAManifest) (*Service, error) {
+	var response CreateServiceResponse
+	if err := s.client.PostContext(ctx, "/owner/services", manifest, &response); err != nil {
+		return nil, err
+	}
+	return &response.Service, nil
+}
+
+// Delete removes a service owned by the current user
+func (s *ServicesService) Delete(ctx context.Context, id string) error {
+	return s.client.DeleteContext(ctx, fmt.Sprintf("/owner/services/%s", url.PathEscape(id)))
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// ToolsService discovers and executes tools
+type ToolsService struct {
+	client *Client
+}
+
+func (c *Client) Tools() *ToolsService {
+	return &ToolsService{client: c}
+}
+
+// Search finds tools matching the query
+func (s *ToolsService) Search(ctx context.Context, query string) (*ToolSearchResponse, error) {
+	var response ToolSearchResponse
+	if err := s.client.GetWithQueryContext(ctx, "/search/tools", map[string]string{"q": query}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *ToolsService) Describe(ctx context.Context, name string) (*ToolDescription, error) {
+	var tool ToolDescription
+	if err := s.client.GetContext(ctx, fmt.Sprintf("/tools/%s/describe", url.PathEscape(name)), &tool); err != nil {
+		return nil, err
+	}
+	return &tool, nil
+}
+
+// Validate checks parameters against the tool schema without executing it
+func (s *ToolsService) Validate(ctx context.Context, name string, parameters map[string]interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	endpoint := fmt.Sprintf("/tools/%s/validate", url.PathEscape(name))
+	if err := s.client.PostContext(ctx, endpoint, ToolExecutionRequest{Parameters: parameters}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *ToolsService) Execute(ctx context.Context, name string, parameters map[string]interface{}) (*ToolExecutionResult, error) {
+	var result ToolExecutionResult
+	endpoint := fmt.Sprintf("/tools/%s/execute", url.PathEscape(name))
+	if err := s.client.PostContext(ctx, endpoint, ToolExecutionRequest{Parameters: parameters}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceBodyLimit is the maximum number of body bytes written to a trace
+const traceBodyLimit = 4096
+
+// sensitiveHeaders are masked in traces
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// sensitiveFields are masked when they appear as keys in JSON bodies
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+	"secret":        true,
+}
+
+var traceMu sync.Mutex
+
+// TraceMiddleware dumps method, URL, redacted headers, truncated bodies,
+// status and latency of every attempt. Traces go to the file at path, or to
+// stderr when path is empty.
+func TraceMiddleware(path string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			var buf bytes.Buffer
+			writeTraceRequest(&buf, req)
+
+			start := time.Now()
+			resp, err := next(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				fmt.Fprintf(&buf, "< error after %s: %v\n", elapsed, err)
+			} else {
+				writeTraceResponse(&buf, resp, elapsed)
+			}
+			buf.WriteString("\n")
+
+			writeTrace(path, buf.Bytes())
+			return resp, err
+		}
+	}
+}
+
+func writeTraceRequest(w io.Writer, req *http.Request) {
+	fmt.Fprintf(w, "--- %s request %s ---\n", time.Now().UTC().Format(time.RFC3339), req.Header.Get("X-Request-ID"))
+	fmt.Fprintf(w, "> %s %s\n", req.Method, req.URL)
+	writeTraceHeaders(w, ">", req.Header)
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			writeTraceBody(w, ">", data)
+		}
+	}
+}
+
+func writeTraceResponse(w io.Writer, resp *http.Response, elapsed time.Duration) {
+	fmt.Fprintf(w, "< %s (%s)\n", resp.Status, elapsed)
+	writeTraceHeaders(w, "<", resp.Header)
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintf(w, "< (failed to read body: %v)\n", err)
+		return
+	}
+	writeTraceBody(w, "<", data)
+}
+
+func writeTraceHeaders(w io.Writer, prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+				value = redactHeader(value)
+			}
+			fmt.Fprintf(w, "%s %s: %s\n", prefix, key, value)
+		}
+	}
+}
+
+func writeTraceBody(w io.Writer, prefix string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	data = redactJSON(data)
+	truncated := len(data) > traceBodyLimit
+	if truncated {
+		data = data[:traceBodyLimit]
+	}
+
+	fmt.Fprintf(w, "%s\n", prefix)
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(w, "%s %s\n", prefix, line)
+	}
+	if truncated {
+		fmt.Fprintf(w, "%s ... (truncated to %d bytes)\n", prefix, traceBodyLimit)
+	}
+}
+
+// redactHeader masks a credential, keeping the auth scheme and the last
+// four characters for identification
+func redactHeader(value string) string {
+	scheme, secret, found := strings.Cut(value, " ")
+	if !found {
+		secret, scheme = scheme, ""
+	}
+
+	masked := "****"
+	if len(secret) > 12 {
+		masked += secret[len(secret)-4:]
+	}
+
+	if scheme == "" {
+		return masked
+	}
+	return scheme + " " + masked
+}
+
+// redactJSON masks sensitive fields in a JSON body; other bodies are returned unchanged
+func redactJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+
+	if !redactValue(v) {
+		return data
+	}
+
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+func redactValue(v interface{}) bool {
+	changed := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if sensitiveFields[strings.ToLower(key)] {
+				if s, ok := item.(string); ok && s != "" {
+					val[key] = "****"
+					changed = true
+				}
+				continue
+			}
+			if redactValue(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func writeTrace(path string, data []byte) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+
+	if path == "" {
+		os.Stderr.Write(data)
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write HTTP trace to %s: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+
+	f.Write(data)
+}
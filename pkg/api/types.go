@@ -100,4 +100,124 @@ type SessionMetrics struct {
 	ActiveSessions    int     `json:"active_sessions"`
 	AverageActivities float64 `json:"average_activities"`
 	AverageDuration   float64 `json:"average_duration"`
-}
\ No newline at end of file
+}
+
+type CreateServiceRequest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Spec        map[string]interface{} `json:"spec,omitempty"`
+}
+
+type CreateServiceResponse struct {
+	Service Service `json:"service"`
+}
+
+// STELLAManifest describes a service and the tools it exposes to agents
+type STELLAManifest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Version     string                 `json:"version"`
+	Tools       []STELLATool           `json:"tools"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+type STELLATool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Method      string                 `json:"method"`
+	Endpoint    string                 `json:"endpoint"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type ToolDescription struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Version     string                 `json:"version"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Examples    []ToolExample          `json:"examples,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+}
+
+type ToolExample struct {
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type ToolSearchResponse struct {
+	Tools []ToolDescription `json:"tools"`
+	Total int               `json:"total"`
+}
+
+type ToolExecutionRequest struct {
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+type ToolExecutionResult struct {
+	Success  bool                   `json:"success"`
+	Result   interface{}            `json:"result,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Duration string                 `json:"duration,omitempty"`
+}
+
+type SaveMemoryRequest struct {
+	SessionID string                 `json:"session_id,omitempty"`
+	Summary   string                 `json:"summary"`
+	Content   map[string]interface{} `json:"content"`
+}
+
+type MemoryListOptions struct {
+	Limit    int
+	Sort     string
+	DateFrom string
+	DateTo   string
+	Cursor   string
+}
+
+type MemorySearchOptions struct {
+	Query     string
+	Limit     int
+	Threshold float64
+}
+
+type AnalyticsOptions struct {
+	Timeframe string
+	UserOnly  bool
+}
+
+// AuthInfo is the OAuth configuration published by the gateway
+type AuthInfo struct {
+	Domain      string `json:"auth0_domain"`
+	ClientID    string `json:"client_id"`
+	Audience    string `json:"auth0_audience"`
+	RedirectURI string `json:"redirect_uri"`
+	AuthURL     string `json:"auth_url"`
+}
+
+// PersonalAccessToken is a long-lived token for non-interactive use such as
+// CI pipelines. The secret itself is only returned when the token is created.
+type PersonalAccessToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	Prefix     string     `json:"prefix,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes,omitempty"`
+	// ExpiresIn is the lifetime in seconds; zero means the token does not expire
+	ExpiresIn int `json:"expires_in,omitempty"`
+}
+
+type CreateTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+type TokensResponse struct {
+	Tokens []PersonalAccessToken `json:"tokens"`
+}
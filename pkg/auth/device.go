@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// deviceCodeGrant is the grant type of the OAuth 2.0 device authorization
+// flow (RFC 8628)
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceCode is the response of the device authorization endpoint. The user
+// enters UserCode at VerificationURI on any device while the CLI polls.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// IssuerURL returns the base URL of the authorization server on the Auth0
+// domain. The scheme of the authorize URL is kept when it is served from the
+// same host, so plain HTTP servers such as the mock gateway work too.
+func IssuerURL(config *OAuthConfig) string {
+	u, err := url.Parse(config.AuthURL)
+	if err != nil {
+		u = &url.URL{}
+	}
+
+	domain := strings.TrimSuffix(config.Domain, "/")
+	if domain == "" {
+		domain = u.Host
+	}
+	if strings.Contains(domain, "://") {
+		return domain
+	}
+
+	scheme := "https"
+	if u.Host == domain && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + domain
+}
+
+// RequestDeviceCode starts a device login on the Auth0 domain
+func RequestDeviceCode(ctx context.Context, config *OAuthConfig) (*DeviceCode, error) {
+	data := url.Values{}
+	data.Set("client_id", config.ClientID)
+	data.Set("scope", Scopes)
+	if config.Audience != "" {
+		data.Set("audience", config.Audience)
+	}
+
+	deviceURL := IssuerURL(config) + "/oauth/device/code"
+	req, err := http.NewRequestWithContext(ctx, "POST", deviceURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create device code request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device code response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr TokenError
+		if err := json.Unmarshal(body, &tokenErr); err == nil && tokenErr.Code != "" {
+			return nil, fmt.Errorf("device authorization failed: %w", &tokenErr)
+		}
+		return nil, fmt.Errorf("device authorization failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	var device DeviceCode
+	if err := json.Unmarshal(body, &device); err != nil {
+		return nil, fmt.Errorf("failed to parse device code response: %w", err)
+	}
+
+	return &device, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user approves or denies
+// the device login, or the device code expires
+func PollDeviceToken(ctx context.Context, config *OAuthConfig, device *DeviceCode) (*TokenResponse, error) {
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	expiresIn := time.Duration(device.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+
+	data := url.Values{}
+	data.Set("grant_type", deviceCodeGrant)
+	data.Set("client_id", config.ClientID)
+	data.Set("device_code", device.DeviceCode)
+
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		tokenResp, err := requestToken(ctx, IssuerURL(config)+"/oauth/token", data)
+		if err == nil {
+			return tokenResp, nil
+		}
+
+		var tokenErr *TokenError
+		if !errors.As(err, &tokenErr) {
+			return nil, fmt.Errorf("failed to poll for token: %w", err)
+		}
+
+		switch tokenErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return nil, fmt.Errorf("the device code expired before the login was approved")
+		case "access_denied":
+			return nil, fmt.Errorf("the login was denied")
+		default:
+			return nil, fmt.Errorf("device login failed: %w", tokenErr)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the device code expired before the login was approved")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are the registered and scope claims of a JWT access token
+type Claims struct {
+	Issuer      string   `json:"iss"`
+	Subject     string   `json:"sub"`
+	Audience    Audience `json:"aud"`
+	ExpiresAt   int64    `json:"exp"`
+	IssuedAt    int64    `json:"iat"`
+	Scope       string   `json:"scope"`
+	Permissions []string `json:"permissions"`
+}
+
+// Audience is the aud claim, which may be a single string or a list
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("invalid aud claim: %w", err)
+	}
+	*a = list
+	return nil
+}
+
+// Expiry returns the exp claim, or the zero time if the token has none
+func (c *Claims) Expiry() time.Time {
+	if c.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Scopes returns the granted scopes from the scope and permissions claims
+func (c *Claims) Scopes() []string {
+	scopes := strings.Fields(c.Scope)
+	for _, permission := range c.Permissions {
+		if !contains(scopes, permission) {
+			scopes = append(scopes, permission)
+		}
+	}
+	return scopes
+}
+
+// DecodeJWT reads the claims of a JWT without verifying its signature. It is
+// meant for displaying what a token grants; only the gateway can tell
+// whether the token is valid.
+func DecodeJWT(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	return &claims, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jwtWith builds a token around a raw JSON payload; the signature is not checked
+func jwtWith(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestDecodeJWT(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    *Claims
+		wantErr string
+	}{
+		{
+			name:  "registered claims with a single audience",
+			token: jwtWith(`{"iss":"https://auth.example.com/","sub":"auth0|123","aud":"https://api.example.com","exp":1767225600,"iat":1767222000}`),
+			want: &Claims{
+				Issuer:    "https://auth.example.com/",
+				Subject:   "auth0|123",
+				Audience:  Audience{"https://api.example.com"},
+				ExpiresAt: 1767225600,
+				IssuedAt:  1767222000,
+			},
+		},
+		{
+			name:  "audience list, scope and permissions",
+			token: jwtWith(`{"sub":"svc@clients","aud":["https://api.example.com","https://auth.example.com/userinfo"],"scope":"openid profile","permissions":["read:tools"]}`),
+			want: &Claims{
+				Subject:     "svc@clients",
+				Audience:    Audience{"https://api.example.com", "https://auth.example.com/userinfo"},
+				Scope:       "openid profile",
+				Permissions: []string{"read:tools"},
+			},
+		},
+		{
+			name:  "padded payload",
+			token: strings.Replace(jwtWith(`{"sub":"a"}`), base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"a"}`)), base64.URLEncoding.EncodeToString([]byte(`{"sub":"a"}`)), 1),
+			want:  &Claims{Subject: "a"},
+		},
+		{
+			name:    "opaque token",
+			token:   "aph_pat_0123456789abcdef",
+			wantErr: "not a JWT",
+		},
+		{
+			name:    "too many parts",
+			token:   "a.b.c.d",
+			wantErr: "not a JWT",
+		},
+		{
+			name:    "payload is not base64",
+			token:   "eyJhbGciOiJub25lIn0.!!!.sig",
+			wantErr: "invalid JWT payload",
+		},
+		{
+			name:    "payload is not JSON",
+			token:   jwtWith(`not json`),
+			wantErr: "invalid JWT claims",
+		},
+		{
+			name:    "audience of the wrong type",
+			token:   jwtWith(`{"aud":42}`),
+			wantErr: "invalid aud claim",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJWT(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DecodeJWT() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeJWT() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeJWT() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsExpiry(t *testing.T) {
+	if got := (&Claims{}).Expiry(); !got.IsZero() {
+		t.Errorf("Expiry() without exp = %v, want the zero time", got)
+	}
+
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := (&Claims{ExpiresAt: want.Unix()}).Expiry(); !got.Equal(want) {
+		t.Errorf("Expiry() = %v, want %v", got, want)
+	}
+}
+
+func TestClaimsScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+		want   []string
+	}{
+		{name: "none", claims: Claims{}, want: []string{}},
+		{name: "scope only", claims: Claims{Scope: "openid  profile email"}, want: []string{"openid", "profile", "email"}},
+		{name: "permissions only", claims: Claims{Permissions: []string{"read:tools", "write:memory"}}, want: []string{"read:tools", "write:memory"}},
+		{
+			name:   "duplicates are merged",
+			claims: Claims{Scope: "openid read:tools", Permissions: []string{"read:tools", "write:memory"}},
+			want:   []string{"openid", "read:tools", "write:memory"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.Scopes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scopes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
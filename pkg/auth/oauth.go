@@ -6,18 +6,30 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"html"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/Exmplr-AI/aphelion-cli/internal/utils"
 )
 
-const (
-	callbackPort = "8765"
-	callbackPath = "/callback"
-	callbackURL  = "http://localhost:" + callbackPort + callbackPath
-)
+// DefaultCallbackPort is where the browser login listens for the redirect
+// unless another port is requested
+const DefaultCallbackPort = 8765
+
+const callbackPath = "/callback"
+
+// CallbackURL returns the redirect URI for a callback server on port
+func CallbackURL(port int) string {
+	return "http://localhost:" + strconv.Itoa(port) + callbackPath
+}
+
+// Scopes requested during login. offline_access asks for a refresh token so
+// long-running jobs keep working after the access token expires.
+const Scopes = "openid profile email offline_access"
 
 type OAuthConfig struct {
 	Domain         string `json:"auth0_domain"`
@@ -27,6 +39,15 @@ type OAuthConfig struct {
 	AuthURL        string `json:"auth_url"`
 	CodeVerifier   string `json:"-"`
 	CodeChallenge  string `json:"-"`
+	// State is sent with the authorization request and must come back
+	// unchanged in the callback, which protects against injected codes
+	State string `json:"-"`
+	// CallbackPort is the preferred port of the local callback server
+	CallbackPort int `json:"-"`
+	// FixedCallbackPort makes the login fail instead of falling back to a
+	// free port when CallbackPort is taken, e.g. because it was chosen to
+	// match a redirect URI registered with the identity provider
+	FixedCallbackPort bool `json:"-"`
 }
 
 type AuthResult struct {
@@ -50,43 +71,79 @@ func GeneratePKCE() (string, string, error) {
 	return verifier, challenge, nil
 }
 
-// StartOAuthFlow starts the OAuth flow and returns the authorization code
-func StartOAuthFlow(config *OAuthConfig) (*AuthResult, error) {
-	// Generate PKCE parameters
+// GenerateState returns a random value for the OAuth state parameter
+func GenerateState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthorizeURL builds the authorization request for config, which must have
+// its PKCE challenge, state and redirect URI set
+func AuthorizeURL(config *OAuthConfig) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", config.ClientID)
+	params.Set("redirect_uri", config.RedirectURI)
+	params.Set("scope", Scopes)
+	params.Set("audience", config.Audience)
+	params.Set("state", config.State)
+	params.Set("code_challenge", config.CodeChallenge)
+	params.Set("code_challenge_method", "S256")
+	return config.AuthURL + "?" + params.Encode()
+}
+
+// PrepareAuthorization sets fresh PKCE parameters and state on config
+func PrepareAuthorization(config *OAuthConfig) error {
 	codeVerifier, codeChallenge, err := GeneratePKCE()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate PKCE parameters: %w", err)
+		return fmt.Errorf("failed to generate PKCE parameters: %w", err)
+	}
+	state, err := GenerateState()
+	if err != nil {
+		return fmt.Errorf("failed to generate state: %w", err)
 	}
-	
+
 	config.CodeVerifier = codeVerifier
 	config.CodeChallenge = codeChallenge
+	config.State = state
+	return nil
+}
 
-	// Create authorization URL with PKCE
-	authURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=openid%%20profile%%20email&audience=%s&code_challenge=%s&code_challenge_method=S256",
-		config.AuthURL,
-		url.QueryEscape(config.ClientID),
-		url.QueryEscape(callbackURL),
-		url.QueryEscape(config.Audience),
-		url.QueryEscape(codeChallenge),
-	)
+// StartOAuthFlow starts the OAuth flow and returns the authorization code
+func StartOAuthFlow(ctx context.Context, config *OAuthConfig) (*AuthResult, error) {
+	if err := PrepareAuthorization(config); err != nil {
+		return nil, err
+	}
+
+	// Listen on loopback only, so the code cannot be delivered from another
+	// machine
+	listener, err := listenCallback(config.CallbackPort, config.FixedCallbackPort)
+	if err != nil {
+		return nil, err
+	}
+	config.RedirectURI = CallbackURL(listener.Addr().(*net.TCPAddr).Port)
+
+	authURL := AuthorizeURL(config)
 
 	// Start local server to handle callback
 	resultChan := make(chan *AuthResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, createCallbackHandler(config.State, resultChan))
 	server := &http.Server{
-		Addr:    ":" + callbackPort,
-		Handler: createCallbackHandler(resultChan),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
 	// Start server in background
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			resultChan <- &AuthResult{Error: fmt.Sprintf("Failed to start callback server: %v", err)}
 		}
 	}()
 
-	// Wait a moment for server to start
-	time.Sleep(100 * time.Millisecond)
-
 	// Open browser
 	utils.OpenBrowserWithFallback(authURL)
 	utils.PrintInfo("Waiting for authentication in browser...")
@@ -98,17 +155,65 @@ func StartOAuthFlow(config *OAuthConfig) (*AuthResult, error) {
 	case result = <-resultChan:
 	case <-time.After(5 * time.Minute):
 		result = &AuthResult{Error: "Authentication timed out after 5 minutes"}
+	case <-ctx.Done():
+		result = &AuthResult{Error: "Authentication cancelled"}
 	}
 
 	// Shutdown server
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	server.Shutdown(ctx)
+	server.Shutdown(shutdownCtx)
 
 	return result, nil
 }
 
-func createCallbackHandler(resultChan chan<- *AuthResult) http.HandlerFunc {
+// listenCallback binds the callback server to port on 127.0.0.1, or, unless
+// the port is fixed, to a free port when it is taken, e.g. by a login running
+// in another terminal
+func listenCallback(port int, fixed bool) (net.Listener, error) {
+	if port <= 0 {
+		port = DefaultCallbackPort
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err == nil {
+		return listener, nil
+	}
+	if fixed {
+		return nil, fmt.Errorf("failed to start callback server: port %d is not available: %w", port, err)
+	}
+
+	listener, fallbackErr := net.Listen("tcp", "127.0.0.1:0")
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+	utils.PrintWarning("Port %d is in use, listening for the login callback on port %d instead", port, listener.Addr().(*net.TCPAddr).Port)
+	return listener, nil
+}
+
+// VerifyCallback checks a redirect URL pasted by the user and returns its
+// authorization code. A bare code is returned as is, since it carries no
+// state to verify.
+func VerifyCallback(input, state string) (string, error) {
+	u, err := url.Parse(input)
+	if err != nil || u.RawQuery == "" {
+		return input, nil
+	}
+
+	query := u.Query()
+	if errorParam := query.Get("error"); errorParam != "" {
+		return "", fmt.Errorf("authentication failed: %s %s", errorParam, query.Get("error_description"))
+	}
+	if query.Get("state") != state {
+		return "", fmt.Errorf("state mismatch: the redirect does not belong to this login")
+	}
+	if query.Get("code") == "" {
+		return "", fmt.Errorf("no authorization code in the redirect URL")
+	}
+	return query.Get("code"), nil
+}
+
+func createCallbackHandler(state string, resultChan chan<- *AuthResult) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Parse query parameters
 		query := r.URL.Query()
@@ -118,74 +223,65 @@ func createCallbackHandler(resultChan chan<- *AuthResult) http.HandlerFunc {
 
 		var result *AuthResult
 
-		if errorParam != "" {
+		switch {
+		case query.Get("state") != state:
+			// Not a response to our request; the login may have been started
+			// by someone else
+			result = &AuthResult{Error: "State mismatch in the login callback; the login was aborted"}
+			writeCallbackPage(w, http.StatusBadRequest, "Authentication Error", "The login response did not match this login request.")
+		case errorParam != "":
 			errorMsg := errorParam
 			if errorDescription != "" {
 				errorMsg += ": " + errorDescription
 			}
 			result = &AuthResult{Error: errorMsg}
-			
-			// Send error response to browser
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Authentication Error - Aphelion CLI</title>
-    <style>
-        body { font-family: Arial, sans-serif; text-align: center; margin-top: 50px; color: #333; }
-        .error { color: #d32f2f; margin: 20px; }
-        .container { max-width: 500px; margin: 0 auto; padding: 20px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>Authentication Error</h1>
-        <div class="error">%s</div>
-        <p>Please return to your terminal and try again.</p>
-        <p>You can close this tab.</p>
-    </div>
-</body>
-</html>`, errorMsg)
-		} else if code != "" {
+			writeCallbackPage(w, http.StatusBadRequest, "Authentication Error", errorMsg)
+		case code != "":
 			result = &AuthResult{Code: code}
-			
-			// Redirect to success page
-			w.Header().Set("Location", "https://aphelion.exmplr.ai/auth/success")
-			w.WriteHeader(http.StatusFound)
-		} else {
+			writeCallbackPage(w, http.StatusOK, "Authentication Successful", "")
+		default:
 			result = &AuthResult{Error: "No authorization code received"}
-			
-			// Send error response
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprintf(w, `
+			writeCallbackPage(w, http.StatusBadRequest, "Authentication Error", "No authorization code received")
+		}
+
+		// Send result to channel
+		select {
+		case resultChan <- result:
+		default:
+		}
+	}
+}
+
+// writeCallbackPage renders the page shown in the browser after the login
+// redirect. An empty message means success.
+func writeCallbackPage(w http.ResponseWriter, status int, title, message string) {
+	body := `<div class="success">You are now logged in to Aphelion CLI.</div>
+        <p>Return to your terminal to continue.</p>`
+	if message != "" {
+		body = `<div class="error">` + html.EscapeString(message) + `</div>
+        <p>Please return to your terminal and try again.</p>`
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `
 <!DOCTYPE html>
 <html>
 <head>
-    <title>Authentication Error - Aphelion CLI</title>
+    <title>%s - Aphelion CLI</title>
     <style>
         body { font-family: Arial, sans-serif; text-align: center; margin-top: 50px; color: #333; }
         .error { color: #d32f2f; margin: 20px; }
+        .success { color: #2e7d32; margin: 20px; }
         .container { max-width: 500px; margin: 0 auto; padding: 20px; }
     </style>
 </head>
 <body>
     <div class="container">
-        <h1>Authentication Error</h1>
-        <div class="error">No authorization code received</div>
-        <p>Please return to your terminal and try again.</p>
+        <h1>%s</h1>
+        %s
         <p>You can close this tab.</p>
     </div>
 </body>
-</html>`)
-		}
-
-		// Send result to channel
-		select {
-		case resultChan <- result:
-		default:
-		}
-	}
-}
\ No newline at end of file
+</html>`, title, title, body)
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,9 +12,39 @@ import (
 )
 
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Expiry returns when the access token expires, or the zero time if the
+// server did not say
+func (t *TokenResponse) Expiry() time.Time {
+	if t.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// TokenURL returns the token endpoint that belongs to the authorize URL
+func TokenURL(config *OAuthConfig) string {
+	return strings.Replace(config.AuthURL, "/authorize", "/oauth/token", 1)
+}
+
+// TokenError is an OAuth error returned by the token endpoint, such as
+// authorization_pending while a device login awaits approval
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
 }
 
 type UserInfo struct {
@@ -23,17 +54,98 @@ type UserInfo struct {
 }
 
 // ExchangeCodeForToken exchanges authorization code for access token
-func ExchangeCodeForToken(config *OAuthConfig, code string) (*TokenResponse, error) {
-	tokenURL := strings.Replace(config.AuthURL, "/authorize", "/oauth/token", 1)
-	
+func ExchangeCodeForToken(ctx context.Context, config *OAuthConfig, code string) (*TokenResponse, error) {
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("client_id", config.ClientID)
 	data.Set("code", code)
-	data.Set("redirect_uri", callbackURL)
+	data.Set("redirect_uri", config.RedirectURI)
 	data.Set("code_verifier", config.CodeVerifier)
 
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	tokenResp, err := requestToken(ctx, TokenURL(config), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+	}
+	return tokenResp, nil
+}
+
+// RefreshAccessToken uses a refresh token to obtain a new access token. The
+// response carries a new refresh token when the server rotates them.
+func RefreshAccessToken(ctx context.Context, tokenURL, clientID, refreshToken string) (*TokenResponse, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("client_id", clientID)
+	data.Set("refresh_token", refreshToken)
+
+	tokenResp, err := requestToken(ctx, tokenURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	return tokenResp, nil
+}
+
+// ClientCredentialsToken obtains an access token for a service account with
+// the client credentials grant. No refresh token is issued; a new access
+// token is requested the same way once it expires.
+func ClientCredentialsToken(ctx context.Context, tokenURL, clientID, clientSecret, audience string) (*TokenResponse, error) {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+	if audience != "" {
+		data.Set("audience", audience)
+	}
+
+	tokenResp, err := requestToken(ctx, tokenURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain client credentials token: %w", err)
+	}
+	return tokenResp, nil
+}
+
+// RevocationURL returns the revocation endpoint that belongs to a token
+// endpoint
+func RevocationURL(tokenURL string) string {
+	return strings.Replace(tokenURL, "/oauth/token", "/oauth/revoke", 1)
+}
+
+// RevokeToken asks the authorization server to revoke a refresh token, which
+// also invalidates the access tokens issued with it (RFC 7009)
+func RevokeToken(ctx context.Context, revokeURL, clientID, token string) error {
+	data := url.Values{}
+	data.Set("client_id", clientID)
+	data.Set("token", token)
+	data.Set("token_type_hint", "refresh_token")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", revokeURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revocation request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var tokenErr TokenError
+		if err := json.Unmarshal(body, &tokenErr); err == nil && tokenErr.Code != "" {
+			return fmt.Errorf("failed to revoke token: %w", &tokenErr)
+		}
+		return fmt.Errorf("failed to revoke token: %s", strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// requestToken posts a grant to the token endpoint
+func requestToken(ctx context.Context, tokenURL string, data url.Values) (*TokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token request: %w", err)
 	}
@@ -43,7 +155,7 @@ func ExchangeCodeForToken(config *OAuthConfig, code string) (*TokenResponse, err
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -53,7 +165,11 @@ func ExchangeCodeForToken(config *OAuthConfig, code string) (*TokenResponse, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed: %s", string(body))
+		var tokenErr TokenError
+		if err := json.Unmarshal(body, &tokenErr); err == nil && tokenErr.Code != "" {
+			return nil, &tokenErr
+		}
+		return nil, fmt.Errorf("token request failed: %s", strings.TrimSpace(string(body)))
 	}
 
 	var tokenResp TokenResponse
@@ -65,10 +181,10 @@ func ExchangeCodeForToken(config *OAuthConfig, code string) (*TokenResponse, err
 }
 
 // GetUserInfo gets user information from Auth0 using the access token
-func GetUserInfo(config *OAuthConfig, accessToken string) (*UserInfo, error) {
+func GetUserInfo(ctx context.Context, config *OAuthConfig, accessToken string) (*UserInfo, error) {
 	userInfoURL := strings.Replace(config.AuthURL, "/authorize", "/userinfo", 1)
 
-	req, err := http.NewRequest("GET", userInfoURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", userInfoURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create userinfo request: %w", err)
 	}
@@ -100,10 +216,10 @@ func GetUserInfo(config *OAuthConfig, accessToken string) (*UserInfo, error) {
 }
 
 // CreateOrGetUser creates or gets user in Aphelion database using Auth0 token
-func CreateOrGetUser(apiBaseURL, accessToken string) (*UserInfo, error) {
+func CreateOrGetUser(ctx context.Context, apiBaseURL, accessToken string) (*UserInfo, error) {
 	url := fmt.Sprintf("%s/auth/test-profile", strings.TrimSuffix(apiBaseURL, "/"))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user profile request: %w", err)
 	}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AccountInfo describes an account logged in to a profile
+type AccountInfo struct {
+	Name      string
+	Current   bool
+	UserID    string
+	Email     string
+	Username  string
+	LastLogin time.Time
+	// Refreshable accounts logged in with OAuth and can renew their tokens
+	Refreshable bool
+	// Service accounts log in with the client credentials grant
+	Service bool
+}
+
+// GetAccountName returns the name of the active account, or an empty string
+// if no account is logged in to the active profile
+func GetAccountName() string {
+	return GetConfig().Account
+}
+
+// ListAccounts returns the accounts of the active profile, sorted by name
+func ListAccounts() []AccountInfo {
+	config := GetConfig()
+	p := getFile().profile(config.Profile, false)
+	if p == nil {
+		return nil
+	}
+
+	accounts := make([]AccountInfo, 0, len(p.Accounts))
+	for name, a := range p.Accounts {
+		if a == nil {
+			continue
+		}
+		accounts = append(accounts, AccountInfo{
+			Name:        name,
+			Current:     name == config.Account,
+			UserID:      a.UserID,
+			Email:       a.Email,
+			Username:    a.Username,
+			LastLogin:   a.LastLogin,
+			Refreshable: a.TokenURL != "",
+			Service:     a.Service,
+		})
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+	return accounts
+}
+
+// HasAccount reports whether name is logged in to the active profile
+func HasAccount(name string) bool {
+	return getFile().account(GetProfileName(), name, false) != nil && name != ""
+}
+
+// UseAccount makes name the current account of the active profile
+func UseAccount(name string) error {
+	if !HasAccount(name) {
+		return fmt.Errorf("account %q does not exist in profile %q", name, GetProfileName())
+	}
+
+	file := getFile()
+	file.profile(GetProfileName(), false).CurrentAccount = name
+	return file.save()
+}
+
+// AccountTokens returns the stored tokens of an account of the active
+// profile, as needed to revoke them when logging out
+func AccountTokens(name string) (TokenSet, error) {
+	profile := GetProfileName()
+	a := getFile().account(profile, name, false)
+	if a == nil || name == "" {
+		return TokenSet{}, fmt.Errorf("account %q does not exist in profile %q", name, profile)
+	}
+
+	tokens := TokenSet{
+		AccessToken:  a.AccessToken,
+		RefreshToken: a.RefreshToken,
+		Expiry:       a.TokenExpiry,
+		TokenURL:     a.TokenURL,
+		ClientID:     a.ClientID,
+	}
+	if a.Service {
+		tokens.ClientSecret = a.ClientSecret
+		tokens.Audience = a.Audience
+	}
+	if tokens.AccessToken == "" {
+		tokens.AccessToken, _ = accountSecret(profile, name, "access_token")
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken, _ = accountSecret(profile, name, "refresh_token")
+	}
+	if tokens.ClientSecret == "" && a.Service {
+		tokens.ClientSecret, _ = accountSecret(profile, name, "client_secret")
+	}
+	return tokens, nil
+}
+
+// StoredClientSecret returns the client secret saved for a service account
+// of the active profile with the given client ID, so logging in again does
+// not need the secret to be entered again
+func StoredClientSecret(clientID string) string {
+	for _, account := range ListAccounts() {
+		if !account.Service {
+			continue
+		}
+		tokens, err := AccountTokens(account.Name)
+		if err == nil && tokens.ClientID == clientID && tokens.ClientSecret != "" {
+			return tokens.ClientSecret
+		}
+	}
+	return ""
+}
+
+// RemoveAccount deletes an account of the active profile along with its
+// stored secrets. When it was the current account, the first remaining
+// account becomes current.
+func RemoveAccount(name string) error {
+	file := getFile()
+	profile := GetProfileName()
+	p := file.profile(profile, false)
+	if p == nil || p.Accounts[name] == nil {
+		return fmt.Errorf("account %q does not exist in profile %q", name, profile)
+	}
+
+	deleteSecrets(profile, file.movedFrom(profile, name))
+	delete(p.Accounts, name)
+	file.dropSecretMove(profile, name)
+
+	if p.CurrentAccount == name {
+		p.CurrentAccount = ""
+		for _, account := range ListAccounts() {
+			p.CurrentAccount = account.Name
+			break
+		}
+	}
+
+	if err := file.save(); err != nil {
+		return err
+	}
+
+	// Reload so the invocation continues with the new current account
+	if GetConfig().Account == name {
+		loadedSecrets = map[string]bool{}
+		InitConfig()
+	}
+	return nil
+}
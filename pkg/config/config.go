@@ -3,49 +3,121 @@ package config
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	APIUrl      string    `yaml:"api_url" mapstructure:"api_url"`
-	AccessToken string    `yaml:"access_token" mapstructure:"access_token"`
-	UserID      string    `yaml:"user_id" mapstructure:"user_id"`
-	Email       string    `yaml:"email" mapstructure:"email"`
-	Username    string    `yaml:"username" mapstructure:"username"`
-	LastLogin   time.Time `yaml:"last_login" mapstructure:"last_login"`
-	Output      string    `yaml:"output" mapstructure:"output"`
+	Profile      string    `yaml:"-" mapstructure:"-"`
+	Account      string    `yaml:"-" mapstructure:"-"`
+	APIUrl       string    `yaml:"api_url" mapstructure:"api_url"`
+	AccessToken  string    `yaml:"access_token" mapstructure:"access_token"`
+	RefreshToken string    `yaml:"refresh_token" mapstructure:"refresh_token"`
+	TokenExpiry  time.Time `yaml:"token_expiry" mapstructure:"token_expiry"`
+	TokenURL     string    `yaml:"token_url" mapstructure:"token_url"`
+	ClientID     string    `yaml:"client_id" mapstructure:"client_id"`
+	ClientSecret string    `yaml:"client_secret" mapstructure:"client_secret"`
+	Audience     string    `yaml:"audience" mapstructure:"audience"`
+	Service      bool      `yaml:"service_account" mapstructure:"service_account"`
+	UserID       string    `yaml:"user_id" mapstructure:"user_id"`
+	Email        string    `yaml:"email" mapstructure:"email"`
+	Username     string    `yaml:"username" mapstructure:"username"`
+	LastLogin    time.Time `yaml:"last_login" mapstructure:"last_login"`
+	Output       string    `yaml:"output" mapstructure:"output"`
+
+	Timeout            time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	Retries            int           `yaml:"retries" mapstructure:"retries"`
+	RetryMaxWait       time.Duration `yaml:"retry_max_wait" mapstructure:"retry_max_wait"`
+	RetryNonIdempotent bool          `yaml:"retry_non_idempotent,omitempty" mapstructure:"retry_non_idempotent"`
 }
 
 var globalConfig *Config
 
-func InitConfig() {
-	viper.SetDefault("api_url", "https://api.aphelion.exmplr.ai")
-	viper.SetDefault("output", "table")
+// loadedSecrets records which secrets the credential store has been
+// consulted for, so backends that prompt for a passphrase are only asked once
+var loadedSecrets = map[string]bool{}
 
-	globalConfig = &Config{
-		APIUrl: viper.GetString("api_url"),
-		Output: viper.GetString("output"),
-	}
+// flags is the command-line flag set checked for explicitly set values
+var flags *pflag.FlagSet
 
-	if viper.IsSet("access_token") {
-		globalConfig.AccessToken = viper.GetString("access_token")
-	}
-	if viper.IsSet("user_id") {
-		globalConfig.UserID = viper.GetString("user_id")
+// SetFlags registers the flag set whose explicitly set flags take
+// precedence over every other source of a setting
+func SetFlags(fs *pflag.FlagSet) {
+	flags = fs
+}
+
+func InitConfig() {
+	file, err := loadFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
-	if viper.IsSet("email") {
-		globalConfig.Email = viper.GetString("email")
+	configFile = file
+
+	name := activeProfileName(file)
+	accountName := activeAccountName(file.profile(name, false))
+	account := file.account(name, accountName, false)
+	if account == nil {
+		account = &Account{}
 	}
-	if viper.IsSet("username") {
-		globalConfig.Username = viper.GetString("username")
+
+	globalConfig = &Config{
+		Profile:            name,
+		Account:            accountName,
+		APIUrl:             stringSetting(file, name, "api_url"),
+		AccessToken:        stringSetting(file, name, "access_token"),
+		RefreshToken:       stringSetting(file, name, "refresh_token"),
+		TokenExpiry:        account.TokenExpiry,
+		TokenURL:           stringSetting(file, name, "token_url"),
+		ClientID:           stringSetting(file, name, "client_id"),
+		ClientSecret:       stringSetting(file, name, "client_secret"),
+		Audience:           stringSetting(file, name, "audience"),
+		UserID:             stringSetting(file, name, "user_id"),
+		Email:              stringSetting(file, name, "email"),
+		Username:           stringSetting(file, name, "username"),
+		LastLogin:          account.LastLogin,
+		Service:            account.Service,
+		Output:             stringSetting(file, name, "output"),
+		Timeout:            durationSetting(file, name, "timeout"),
+		Retries:            intSetting(file, name, "retries"),
+		RetryMaxWait:       durationSetting(file, name, "retry_max_wait"),
+		RetryNonIdempotent: boolSetting(file, name, "retry_non_idempotent"),
 	}
-	if viper.IsSet("last_login") {
-		globalConfig.LastLogin = viper.GetTime("last_login")
+}
+
+// setting resolves a key, falling back to its default with a warning when
+// the configured value is invalid
+func setting(file *fileConfig, profile, name string) string {
+	key := mustKey(name)
+	value, source := resolveKey(file, profile, key)
+	if source != SourceDefault {
+		if err := key.Validate(value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (from %s), using default %q\n", err, source, key.Default)
+			return key.Default
+		}
 	}
+	return value
+}
+
+func stringSetting(file *fileConfig, profile, name string) string {
+	return setting(file, profile, name)
+}
+
+func durationSetting(file *fileConfig, profile, name string) time.Duration {
+	d, _ := time.ParseDuration(setting(file, profile, name))
+	return d
+}
+
+func intSetting(file *fileConfig, profile, name string) int {
+	n, _ := strconv.Atoi(setting(file, profile, name))
+	return n
+}
+
+func boolSetting(file *fileConfig, profile, name string) bool {
+	b, _ := strconv.ParseBool(setting(file, profile, name))
+	return b
 }
 
 func GetConfig() *Config {
@@ -55,74 +127,80 @@ func GetConfig() *Config {
 	return globalConfig
 }
 
+// SaveConfig writes the active account's credentials to the config file and
+// the tokens to the credential store. Values that came from flags or
+// the environment are not persisted.
 func SaveConfig() error {
 	if globalConfig == nil {
 		return fmt.Errorf("config not initialized")
 	}
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("could not get home directory: %w", err)
+	if globalConfig.Account == "" {
+		globalConfig.Account = accountName(globalConfig.UserID, globalConfig.Email, globalConfig.Username)
 	}
 
-	configDir := filepath.Join(home, ".aphelion")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("could not create config directory: %w", err)
+	file := getFile()
+	name := globalConfig.Account
+	account := file.account(globalConfig.Profile, name, true)
+	if err := saveSecret(file, globalConfig.Profile, name, "access_token", globalConfig.AccessToken); err != nil {
+		return err
 	}
-
-	configFile := filepath.Join(configDir, "config.yaml")
-	
-	data, err := yaml.Marshal(globalConfig)
-	if err != nil {
-		return fmt.Errorf("could not marshal config: %w", err)
+	if err := saveSecret(file, globalConfig.Profile, name, "refresh_token", globalConfig.RefreshToken); err != nil {
+		return err
 	}
-
-	if err := os.WriteFile(configFile, data, 0600); err != nil {
-		return fmt.Errorf("could not write config file: %w", err)
+	// A client secret from the environment stays there
+	if _, _, ok := mustKey("client_secret").lookupEnv(); !ok || !globalConfig.Service {
+		if err := saveSecret(file, globalConfig.Profile, name, "client_secret", globalConfig.ClientSecret); err != nil {
+			return err
+		}
 	}
+	account.TokenExpiry = globalConfig.TokenExpiry
+	account.TokenURL = globalConfig.TokenURL
+	account.ClientID = globalConfig.ClientID
+	account.Audience = globalConfig.Audience
+	account.Service = globalConfig.Service
+	account.UserID = globalConfig.UserID
+	account.Email = globalConfig.Email
+	account.Username = globalConfig.Username
+	account.LastLogin = globalConfig.LastLogin
 
-	viper.Set("access_token", globalConfig.AccessToken)
-	viper.Set("user_id", globalConfig.UserID)
-	viper.Set("email", globalConfig.Email)
-	viper.Set("username", globalConfig.Username)
-	viper.Set("last_login", globalConfig.LastLogin)
+	if profile := file.profile(globalConfig.Profile, false); profile.CurrentAccount == "" {
+		profile.CurrentAccount = name
+	}
+	if file.CurrentContext == "" {
+		file.CurrentContext = globalConfig.Profile
+	}
 
-	return nil
+	return file.save()
 }
 
+// SetAuth saves a token that cannot be refreshed, as issued by the
+// username/password login
 func SetAuth(token, userID, email, username string) error {
-	config := GetConfig()
-	config.AccessToken = token
-	config.UserID = userID
-	config.Email = email
-	config.Username = username
-	config.LastLogin = time.Now()
-	
-	return SaveConfig()
-}
-
-func ClearAuth() error {
-	config := GetConfig()
-	config.AccessToken = ""
-	config.UserID = ""
-	config.Email = ""
-	config.Username = ""
-	config.LastLogin = time.Time{}
-	
-	return SaveConfig()
+	return SetAuthTokens(TokenSet{AccessToken: token}, userID, email, username)
 }
 
 func IsAuthenticated() bool {
-	config := GetConfig()
-	return config.AccessToken != ""
+	return GetAccessToken() != ""
 }
 
 func GetAPIUrl() string {
 	return GetConfig().APIUrl
 }
 
+// GetAccessToken returns the access token, reading it from the credential
+// store on first use when it is not set by the environment or config file
 func GetAccessToken() string {
-	return GetConfig().AccessToken
+	return loadSecret("access_token", &GetConfig().AccessToken)
+}
+
+// loadSecret fills value from the credential store the first time it is
+// needed, unless it is already set by the environment or config file
+func loadSecret(key string, value *string) string {
+	if *value == "" && !loadedSecrets[key] {
+		loadedSecrets[key] = true
+		*value, _ = storedSecret(key)
+	}
+	return *value
 }
 
 func GetUserID() string {
@@ -131,4 +209,50 @@ func GetUserID() string {
 
 func GetOutputFormat() string {
 	return GetConfig().Output
-}
\ No newline at end of file
+}
+
+func GetTimeout() time.Duration {
+	return GetConfig().Timeout
+}
+
+// SetTimeout overrides the request timeout for the current invocation without
+// persisting it
+func SetTimeout(timeout time.Duration) {
+	GetConfig().Timeout = timeout
+}
+
+func GetRetries() int {
+	return GetConfig().Retries
+}
+
+func GetRetryMaxWait() time.Duration {
+	return GetConfig().RetryMaxWait
+}
+
+func GetRetryNonIdempotent() bool {
+	return GetConfig().RetryNonIdempotent
+}
+
+func IsVerbose() bool {
+	return viper.GetBool("verbose")
+}
+
+// IsHTTPDebug reports whether wire-level HTTP tracing is enabled, either
+// directly or by setting a trace file
+func IsHTTPDebug() bool {
+	return viper.GetBool("debug_http") || viper.GetString("trace_file") != ""
+}
+
+func GetTraceFile() string {
+	return viper.GetString("trace_file")
+}
+
+// GetRecordPath returns the cassette file gateway traffic is recorded to, if any
+func GetRecordPath() string {
+	return viper.GetString("record")
+}
+
+// GetReplayPath returns the cassette file gateway responses are replayed from, if any
+func GetReplayPath() string {
+	return viper.GetString("replay")
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Credential store backends, selected with the credential_store setting
+const (
+	CredentialStoreAuto      = "auto"
+	CredentialStoreKeyring   = "keyring"
+	CredentialStoreFile      = "file"
+	CredentialStorePlaintext = "plaintext"
+)
+
+// CredentialStore keeps secrets such as access tokens, per profile account
+type CredentialStore interface {
+	// Name identifies the backend
+	Name() string
+	// Get returns the stored secret, or an empty string if there is none
+	Get(profile, account, key string) (string, error)
+	Set(profile, account, key, value string) error
+	Delete(profile, account, key string) error
+}
+
+// credentialID names the secrets of an account in stores that are not
+// structured by profile. Secrets saved before accounts existed are kept under
+// the profile name alone.
+func credentialID(profile, account string) string {
+	if account == "" {
+		return profile
+	}
+	return profile + "/" + account
+}
+
+// secretKeys are the account settings kept in the credential store
+var secretKeys = []string{"access_token", "refresh_token", "client_secret"}
+
+var credentialStore CredentialStore
+
+// GetCredentialStore returns the backend selected by credential_store. In
+// auto mode the OS keyring is used when it is reachable, otherwise tokens
+// stay in the config file.
+func GetCredentialStore() (CredentialStore, error) {
+	if credentialStore != nil {
+		return credentialStore, nil
+	}
+
+	file := getFile()
+	name, _ := resolveKey(file, GetProfileName(), mustKey("credential_store"))
+
+	switch name {
+	case CredentialStoreKeyring:
+		credentialStore = &keyringStore{}
+	case CredentialStoreFile:
+		path, err := ConfigFilePath()
+		if err != nil {
+			return nil, err
+		}
+		credentialStore = newEncryptedFileStore(credentialsFilePath(path))
+	case CredentialStorePlaintext:
+		credentialStore = &plaintextStore{file: file}
+	default:
+		if keyringAvailable() {
+			credentialStore = &keyringStore{}
+		} else {
+			credentialStore = &plaintextStore{file: file, fallback: true}
+		}
+	}
+
+	return credentialStore, nil
+}
+
+// keyringAvailable probes the OS keyring. On Linux the Secret Service is only
+// tried when a D-Bus session is running, so headless machines fall back
+// without trying to start one.
+func keyringAvailable() bool {
+	if runtime.GOOS == "linux" && os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return false
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&keyringStore{}).Get("", "", "probe")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err == nil
+	case <-time.After(3 * time.Second):
+		return false
+	}
+}
+
+// storedSecret reads a secret for the active account from a non-plaintext
+// credential store. Plaintext secrets are resolved with the other settings.
+func storedSecret(key string) (string, Source) {
+	config := GetConfig()
+	return accountSecret(config.Profile, config.Account, key)
+}
+
+// accountSecret reads a secret for an account of profile from a
+// non-plaintext credential store
+func accountSecret(profile, account, key string) (string, Source) {
+	store, err := GetCredentialStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return "", SourceDefault
+	}
+	if _, ok := store.(*plaintextStore); ok {
+		return "", SourceDefault
+	}
+
+	value, err := store.Get(profile, getFile().movedFrom(profile, account), key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not read %s from %s credential store: %v\n", key, store.Name(), err)
+		return "", SourceDefault
+	}
+	if value == "" {
+		return "", SourceDefault
+	}
+	return value, SourceStore
+}
+
+// saveSecret writes a secret for an account of profile to the credential
+// store. Any plaintext copy left in the config file is removed, which
+// migrates tokens saved before a keyring or encrypted store was configured.
+func saveSecret(file *fileConfig, profile, account, key, value string) error {
+	store, err := GetCredentialStore()
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		err = store.Delete(profile, account, key)
+	} else {
+		err = store.Set(profile, account, key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("could not save %s to %s credential store: %w", key, store.Name(), err)
+	}
+
+	if _, ok := store.(*plaintextStore); !ok {
+		if a := file.account(profile, account, false); a != nil {
+			a.set(key, "")
+		}
+	}
+	return nil
+}
+
+// deleteSecrets removes every secret stored for an account of profile
+func deleteSecrets(profile, account string) {
+	store, err := GetCredentialStore()
+	if err != nil {
+		return
+	}
+	for _, key := range secretKeys {
+		store.Delete(profile, account, key)
+	}
+}
+
+// moveSecrets moves the stored secrets of accounts migrated from legacy
+// profile credentials to the accounts' own entries. Moves that fail are
+// retried on the next save.
+func (f *fileConfig) moveSecrets() {
+	if len(f.secretMoves) == 0 {
+		return
+	}
+	store, err := GetCredentialStore()
+	if err != nil {
+		return
+	}
+	if _, ok := store.(*plaintextStore); ok {
+		f.secretMoves = nil
+		return
+	}
+
+	var pending []secretMove
+	for _, move := range f.secretMoves {
+		if err := moveSecret(store, move); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not move credentials of profile %q to account %q: %v\n", move.profile, move.account, err)
+			pending = append(pending, move)
+		}
+	}
+	f.secretMoves = pending
+}
+
+func moveSecret(store CredentialStore, move secretMove) error {
+	for _, key := range secretKeys {
+		value, err := store.Get(move.profile, "", key)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		if err := store.Set(move.profile, move.account, key, value); err != nil {
+			return err
+		}
+		if err := store.Delete(move.profile, "", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plaintextStore keeps secrets in the account sections of the config file.
+// When auto mode falls back to it for lack of a keyring, the first secret
+// saved comes with a warning, since nobody chose to store it in plaintext.
+type plaintextStore struct {
+	file     *fileConfig
+	fallback bool
+	warned   bool
+}
+
+func (s *plaintextStore) Name() string {
+	return CredentialStorePlaintext
+}
+
+func (s *plaintextStore) Get(profile, account, key string) (string, error) {
+	if a := s.file.account(profile, account, false); a != nil {
+		return a.get(key), nil
+	}
+	return "", nil
+}
+
+// Set updates the account in memory; the config file is written by the caller
+func (s *plaintextStore) Set(profile, account, key, value string) error {
+	if s.fallback && !s.warned {
+		s.warned = true
+		path, _ := ConfigFilePath()
+		fmt.Fprintf(os.Stderr, "Warning: no OS keyring is reachable, so credentials are saved in plaintext in %s. "+
+			"Run 'aphelion config set credential_store file' to encrypt them with a passphrase, "+
+			"or set credential_store to plaintext to silence this warning\n", path)
+	}
+	s.file.account(profile, account, true).set(key, value)
+	return nil
+}
+
+func (s *plaintextStore) Delete(profile, account, key string) error {
+	if a := s.file.account(profile, account, false); a != nil {
+		a.set(key, "")
+	}
+	return nil
+}
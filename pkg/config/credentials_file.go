@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv supplies the passphrase of the encrypted credentials file
+// for non-interactive use
+const PassphraseEnv = "APHELION_CREDENTIALS_PASSPHRASE"
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// encryptedFile is the on-disk layout of the credentials file. Secrets are
+// sealed with AES-256-GCM under a key derived from the passphrase.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// encryptedFileStore keeps secrets in a passphrase-encrypted file next to
+// the config file
+type encryptedFileStore struct {
+	path       string
+	passphrase []byte
+	secrets    map[string]map[string]string
+}
+
+func newEncryptedFileStore(path string) *encryptedFileStore {
+	return &encryptedFileStore{path: path}
+}
+
+// credentialsFilePath returns the encrypted credentials file that belongs to
+// the config file at configPath
+func credentialsFilePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "credentials.enc")
+}
+
+func (s *encryptedFileStore) Name() string {
+	return CredentialStoreFile
+}
+
+func (s *encryptedFileStore) Get(profile, account, key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	return s.secrets[credentialID(profile, account)][key], nil
+}
+
+func (s *encryptedFileStore) Set(profile, account, key, value string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	id := credentialID(profile, account)
+	if s.secrets[id] == nil {
+		s.secrets[id] = map[string]string{}
+	}
+	s.secrets[id][key] = value
+	return s.save()
+}
+
+func (s *encryptedFileStore) Delete(profile, account, key string) error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	id := credentialID(profile, account)
+	if _, ok := s.secrets[id][key]; !ok {
+		return nil
+	}
+	delete(s.secrets[id], key)
+	if len(s.secrets[id]) == 0 {
+		delete(s.secrets, id)
+	}
+	return s.save()
+}
+
+func (s *encryptedFileStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.secrets = map[string]map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read credentials file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("could not parse credentials file %s: %w", s.path, err)
+	}
+	if file.Version != 1 || file.KDF != "scrypt" {
+		return fmt.Errorf("unsupported credentials file format in %s", s.path)
+	}
+
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return fmt.Errorf("could not decrypt %s: wrong passphrase or corrupted file", s.path)
+	}
+
+	secrets := map[string]map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("could not parse decrypted credentials: %w", err)
+	}
+
+	s.secrets = secrets
+	return nil
+}
+
+func (s *encryptedFileStore) save() error {
+	passphrase, err := s.getPassphrase(true)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("could not marshal credentials: %w", err)
+	}
+
+	file := encryptedFile{Version: 1, KDF: "scrypt", Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("could not generate salt: %w", err)
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("could not generate nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal credentials file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("could not write credentials file: %w", err)
+	}
+
+	return nil
+}
+
+// getPassphrase reads the passphrase from the environment or the terminal.
+// A new passphrase (create) is asked for twice.
+func (s *encryptedFileStore) getPassphrase(create bool) ([]byte, error) {
+	if s.passphrase != nil {
+		return s.passphrase, nil
+	}
+
+	if value, ok := os.LookupEnv(PassphraseEnv); ok {
+		s.passphrase = []byte(value)
+		return s.passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("the encrypted credential store needs a passphrase: set %s or run interactively", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Credentials passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	if create {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		confirm, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if !bytes.Equal(passphrase, confirm) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	s.passphrase = passphrase
+	return s.passphrase, nil
+}
+
+func newGCM(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("could not derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
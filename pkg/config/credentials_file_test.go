@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileStoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []struct{ profile, account, key, value string }
+	}{
+		{
+			name: "single token",
+			secrets: []struct{ profile, account, key, value string }{
+				{"default", "alice", "access_token", "eyJhbGciOiJub25lIn0.e30."},
+			},
+		},
+		{
+			name: "several profiles and accounts",
+			secrets: []struct{ profile, account, key, value string }{
+				{"default", "alice", "access_token", "token-a"},
+				{"default", "alice", "refresh_token", "refresh-a"},
+				{"default", "bob", "access_token", "token-b"},
+				{"staging", "", "client_secret", "s3cr3t with spaces and ünïcode"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(PassphraseEnv, "correct horse battery staple")
+			path := filepath.Join(t.TempDir(), "credentials.enc")
+
+			store := newEncryptedFileStore(path)
+			for _, s := range tt.secrets {
+				if err := store.Set(s.profile, s.account, s.key, s.value); err != nil {
+					t.Fatalf("Set(%s, %s, %s) failed: %v", s.profile, s.account, s.key, err)
+				}
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("credentials file was not written: %v", err)
+			}
+			for _, s := range tt.secrets {
+				if bytes.Contains(data, []byte(s.value)) {
+					t.Errorf("credentials file contains the plaintext secret %q", s.value)
+				}
+			}
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+				t.Errorf("credentials file mode = %v, want 0600", info.Mode().Perm())
+			}
+
+			// A fresh store has to decrypt the file from disk
+			reopened := newEncryptedFileStore(path)
+			for _, s := range tt.secrets {
+				got, err := reopened.Get(s.profile, s.account, s.key)
+				if err != nil {
+					t.Fatalf("Get(%s, %s, %s) failed: %v", s.profile, s.account, s.key, err)
+				}
+				if got != s.value {
+					t.Errorf("Get(%s, %s, %s) = %q, want %q", s.profile, s.account, s.key, got, s.value)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptedFileStoreDelete(t *testing.T) {
+	t.Setenv(PassphraseEnv, "passphrase")
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+
+	store := newEncryptedFileStore(path)
+	if err := store.Set("default", "alice", "access_token", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("default", "alice", "access_token"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := newEncryptedFileStore(path).Get("default", "alice", "access_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("Get after Delete = %q, want empty", got)
+	}
+}
+
+func TestEncryptedFileStoreRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+		wantErr string
+	}{
+		{
+			name:    "wrong passphrase",
+			corrupt: func(t *testing.T, path string) { t.Setenv(PassphraseEnv, "wrong") },
+			wantErr: "wrong passphrase or corrupted file",
+		},
+		{
+			name: "tampered ciphertext",
+			corrupt: func(t *testing.T, path string) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Flip a character of the base64 ciphertext
+				i := bytes.Index(data, []byte(`"data": "`)) + len(`"data": "`)
+				if data[i] == 'A' {
+					data[i] = 'B'
+				} else {
+					data[i] = 'A'
+				}
+				if err := os.WriteFile(path, data, 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "wrong passphrase or corrupted file",
+		},
+		{
+			name: "unknown format",
+			corrupt: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte(`{"version": 2, "kdf": "argon2"}`), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: "unsupported credentials file format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(PassphraseEnv, "passphrase")
+			path := filepath.Join(t.TempDir(), "credentials.enc")
+			if err := newEncryptedFileStore(path).Set("default", "alice", "access_token", "token"); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.corrupt(t, path)
+
+			_, err := newEncryptedFileStore(path).Get("default", "alice", "access_token")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Get() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
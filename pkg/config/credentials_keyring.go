@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service name secrets are stored under in the OS
+// keyring (Secret Service on Linux, Keychain on macOS, Credential Manager on
+// Windows)
+const keyringService = "aphelion-cli"
+
+// keyringStore keeps secrets in the OS keyring
+type keyringStore struct{}
+
+func (s *keyringStore) Name() string {
+	return CredentialStoreKeyring
+}
+
+func keyringUser(profile, account, key string) string {
+	return credentialID(profile, account) + "/" + key
+}
+
+func (s *keyringStore) Get(profile, account, key string) (string, error) {
+	value, err := keyring.Get(keyringService, keyringUser(profile, account, key))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	return value, err
+}
+
+func (s *keyringStore) Set(profile, account, key, value string) error {
+	return keyring.Set(keyringService, keyringUser(profile, account, key), value)
+}
+
+func (s *keyringStore) Delete(profile, account, key string) error {
+	err := keyring.Delete(keyringService, keyringUser(profile, account, key))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scope determines where a setting is stored in the config file
+type Scope string
+
+const (
+	// ScopeProfile settings are stored per profile, falling back to the
+	// top level of the config file
+	ScopeProfile Scope = "profile"
+	// ScopeAccount settings are the credentials of the active account of
+	// the active profile
+	ScopeAccount Scope = "account"
+	// ScopeGlobal settings are stored at the top level of the config file
+	// and apply to every profile
+	ScopeGlobal Scope = "global"
+)
+
+// Source identifies where an effective setting came from
+type Source string
+
+// Settings are resolved in this order, first match wins
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceProfile Source = "profile"
+	SourceStore   Source = "credential store"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Kind is the type of a setting's value
+type Kind string
+
+const (
+	KindString   Kind = "string"
+	KindDuration Kind = "duration"
+	KindInt      Kind = "int"
+	KindBool     Kind = "bool"
+)
+
+// Key describes a configuration setting
+type Key struct {
+	Name        string
+	Flag        string
+	Scope       Scope
+	Kind        Kind
+	Default     string
+	Description string
+	// Secret values are redacted when displayed
+	Secret bool
+	// Managed values are written by the auth commands and cannot be set
+	// with 'aphelion config set'
+	Managed bool
+	// EnvAliases are further environment variables that override the key,
+	// checked after EnvVar
+	EnvAliases []string
+	validate   func(value string) error
+}
+
+// EnvVar returns the environment variable that overrides the key
+func (k Key) EnvVar() string {
+	return "APHELION_" + strings.ToUpper(k.Name)
+}
+
+// lookupEnv returns the value of the first environment variable set for the
+// key and its name
+func (k Key) lookupEnv() (string, string, bool) {
+	for _, name := range append([]string{k.EnvVar()}, k.EnvAliases...) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, name, true
+		}
+	}
+	return "", "", false
+}
+
+// Validate checks that value is acceptable for the key
+func (k Key) Validate(value string) error {
+	if _, err := k.parse(value); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, k.Name, err)
+	}
+	return nil
+}
+
+// parse converts value to the key's kind, as stored in the config file
+func (k Key) parse(value string) (interface{}, error) {
+	var parsed interface{} = value
+	var err error
+
+	switch k.Kind {
+	case KindDuration:
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil && d < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+	case KindInt:
+		var n int
+		if n, err = strconv.Atoi(value); err == nil && n < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		parsed = n
+	case KindBool:
+		parsed, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if k.validate != nil {
+		if err := k.validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+var keys = []Key{
+	{Name: "api_url", Flag: "api-url", Scope: ScopeProfile, Kind: KindString, Default: "https://api.aphelion.exmplr.ai", Description: "API base URL", validate: validateURL},
+	{Name: "output", Flag: "output", Scope: ScopeProfile, Kind: KindString, Default: "table", Description: "default output format (json|yaml|table)", validate: validateOutput},
+	{Name: "access_token", Scope: ScopeAccount, Kind: KindString, Description: "access token for the gateway", Secret: true, Managed: true, EnvAliases: []string{TokenEnv}},
+	{Name: "refresh_token", Scope: ScopeAccount, Kind: KindString, Description: "OAuth refresh token used to renew the access token", Secret: true, Managed: true},
+	{Name: "token_expiry", Scope: ScopeAccount, Kind: KindString, Description: "time the access token expires", Managed: true},
+	{Name: "token_url", Scope: ScopeAccount, Kind: KindString, Description: "OAuth token endpoint used for refreshes", Managed: true},
+	{Name: "client_id", Scope: ScopeAccount, Kind: KindString, Description: "OAuth client the tokens were issued to", Managed: true},
+	{Name: "client_secret", Scope: ScopeAccount, Kind: KindString, Description: "OAuth client secret of a service account", Secret: true, Managed: true},
+	{Name: "audience", Scope: ScopeAccount, Kind: KindString, Description: "API audience requested for service account tokens", Managed: true},
+	{Name: "user_id", Scope: ScopeAccount, Kind: KindString, Description: "ID of the logged in user", Managed: true},
+	{Name: "email", Scope: ScopeAccount, Kind: KindString, Description: "email of the logged in user", Managed: true},
+	{Name: "username", Scope: ScopeAccount, Kind: KindString, Description: "name of the logged in user", Managed: true},
+	{Name: "last_login", Scope: ScopeAccount, Kind: KindString, Description: "time of the last login", Managed: true},
+	{Name: "timeout", Flag: "timeout", Scope: ScopeGlobal, Kind: KindDuration, Default: "30s", Description: "timeout for each API request"},
+	{Name: "retries", Flag: "retries", Scope: ScopeGlobal, Kind: KindInt, Default: "3", Description: "number of retries for transient API failures"},
+	{Name: "retry_max_wait", Flag: "retry-max-wait", Scope: ScopeGlobal, Kind: KindDuration, Default: "30s", Description: "maximum wait between API retries"},
+	{Name: "credential_store", Scope: ScopeGlobal, Kind: KindString, Default: CredentialStoreAuto, Description: "where access tokens are kept (auto|keyring|file|plaintext)", validate: validateCredentialStore},
+	{Name: "retry_non_idempotent", Scope: ScopeGlobal, Kind: KindBool, Default: "false", Description: "also retry POST and PATCH requests"},
+}
+
+// Keys returns every known setting, sorted by name
+func Keys() []Key {
+	sorted := make([]Key, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// LookupKey finds a setting by name. Dashes are accepted in place of
+// underscores, so flag-style names such as api-url work too.
+func LookupKey(name string) (Key, error) {
+	name = strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	for _, key := range keys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+	return Key{}, fmt.Errorf("unknown config key %q", name)
+}
+
+func mustKey(name string) Key {
+	key, err := LookupKey(name)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// Value returns the effective value of a setting for the active profile and
+// where it came from. Precedence is flag > env > profile > file > default;
+// secrets not found elsewhere are read from the credential store.
+func Value(name string) (string, Source, error) {
+	key, err := LookupKey(name)
+	if err != nil {
+		return "", "", err
+	}
+	value, source := resolveKey(getFile(), GetProfileName(), key)
+	if key.Secret && source == SourceDefault {
+		if stored, storeSource := storedSecret(key.Name); stored != "" {
+			return stored, storeSource, nil
+		}
+	}
+	return value, source, nil
+}
+
+func resolveKey(file *fileConfig, profileName string, key Key) (string, Source) {
+	if flags != nil && key.Flag != "" {
+		if f := flags.Lookup(key.Flag); f != nil && f.Changed {
+			return f.Value.String(), SourceFlag
+		}
+	}
+
+	if value, _, ok := key.lookupEnv(); ok {
+		return value, SourceEnv
+	}
+
+	if p := file.profile(profileName, false); p != nil {
+		switch key.Scope {
+		case ScopeProfile:
+			if value := p.get(key.Name); value != "" {
+				return value, SourceProfile
+			}
+		case ScopeAccount:
+			if a := file.account(profileName, activeAccountName(p), false); a != nil {
+				if value := a.get(key.Name); value != "" {
+					return value, SourceProfile
+				}
+			}
+		}
+	}
+
+	if value, ok := file.Settings[key.Name]; ok && value != nil {
+		return fmt.Sprint(value), SourceFile
+	}
+
+	return key.Default, SourceDefault
+}
+
+// Redact masks a secret for display, keeping the last four characters of
+// long values for identification
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) > 12 {
+		return "****" + value[len(value)-4:]
+	}
+	return "****"
+}
+
+// SetValue writes a setting to the config file: profile settings go to the
+// active profile unless global is set, other settings to the top level
+func SetValue(name, value string, global bool) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if key.Managed {
+		return fmt.Errorf("%s is managed by 'aphelion auth login' and cannot be set directly", key.Name)
+	}
+	parsed, err := key.parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key.Name, err)
+	}
+
+	file := getFile()
+	if key.Scope == ScopeProfile && !global {
+		file.profile(GetProfileName(), true).set(key.Name, value)
+	} else {
+		if file.Settings == nil {
+			file.Settings = map[string]interface{}{}
+		}
+		file.Settings[key.Name] = parsed
+	}
+
+	return file.save()
+}
+
+// UnsetValue removes a setting from the active profile, or from the top
+// level of the config file for global settings or when global is set
+func UnsetValue(name string, global bool) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if key.Managed {
+		return fmt.Errorf("%s is managed by 'aphelion auth login'; use 'aphelion auth logout' to clear it", key.Name)
+	}
+
+	file := getFile()
+	if key.Scope == ScopeProfile && !global {
+		if p := file.profile(GetProfileName(), false); p != nil {
+			p.set(key.Name, "")
+		}
+	} else {
+		delete(file.Settings, key.Name)
+	}
+
+	return file.save()
+}
+
+func validateURL(value string) error {
+	if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+		return fmt.Errorf("must start with http:// or https://")
+	}
+	return nil
+}
+
+func validateOutput(value string) error {
+	switch value {
+	case "json", "yaml", "table":
+		return nil
+	}
+	return fmt.Errorf("must be one of json, yaml or table")
+}
+
+func validateCredentialStore(value string) error {
+	switch value {
+	case CredentialStoreAuto, CredentialStoreKeyring, CredentialStoreFile, CredentialStorePlaintext:
+		return nil
+	}
+	return fmt.Errorf("must be one of auto, keyring, file or plaintext")
+}
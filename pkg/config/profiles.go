@@ -0,0 +1,467 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is used when no profile is selected and is where settings
+// from configs written before profiles existed are migrated to
+const DefaultProfile = "default"
+
+// Profile holds the settings of a named context: the gateway it talks to,
+// output defaults and the accounts logged in to it
+type Profile struct {
+	APIUrl         string              `yaml:"api_url,omitempty"`
+	Output         string              `yaml:"output,omitempty"`
+	CurrentAccount string              `yaml:"current_account,omitempty"`
+	Accounts       map[string]*Account `yaml:"accounts,omitempty"`
+	// Legacy holds credentials saved before accounts existed; they are
+	// moved to an account when the file is loaded
+	Legacy Account `yaml:",inline"`
+}
+
+// Account holds the credentials of a user logged in to a profile. Service
+// accounts log in with the client credentials grant and renew their token
+// with the client secret instead of a refresh token.
+type Account struct {
+	AccessToken  string    `yaml:"access_token,omitempty"`
+	RefreshToken string    `yaml:"refresh_token,omitempty"`
+	TokenExpiry  time.Time `yaml:"token_expiry,omitempty"`
+	TokenURL     string    `yaml:"token_url,omitempty"`
+	ClientID     string    `yaml:"client_id,omitempty"`
+	ClientSecret string    `yaml:"client_secret,omitempty"`
+	Audience     string    `yaml:"audience,omitempty"`
+	Service      bool      `yaml:"service_account,omitempty"`
+	UserID       string    `yaml:"user_id,omitempty"`
+	Email        string    `yaml:"email,omitempty"`
+	Username     string    `yaml:"username,omitempty"`
+	LastLogin    time.Time `yaml:"last_login,omitempty"`
+}
+
+// get returns a profile setting by config key
+func (p *Profile) get(name string) string {
+	switch name {
+	case "api_url":
+		return p.APIUrl
+	case "output":
+		return p.Output
+	}
+	return ""
+}
+
+// set updates a profile setting by config key
+func (p *Profile) set(name, value string) {
+	switch name {
+	case "api_url":
+		p.APIUrl = value
+	case "output":
+		p.Output = value
+	}
+}
+
+// Current returns the profile's current account, or nil if none is logged in
+func (p *Profile) Current() *Account {
+	return p.Accounts[p.CurrentAccount]
+}
+
+// get returns an account setting by config key
+func (a *Account) get(name string) string {
+	switch name {
+	case "access_token":
+		return a.AccessToken
+	case "refresh_token":
+		return a.RefreshToken
+	case "token_url":
+		return a.TokenURL
+	case "client_id":
+		return a.ClientID
+	case "client_secret":
+		return a.ClientSecret
+	case "audience":
+		return a.Audience
+	case "user_id":
+		return a.UserID
+	case "email":
+		return a.Email
+	case "username":
+		return a.Username
+	case "last_login":
+		if !a.LastLogin.IsZero() {
+			return a.LastLogin.Format(time.RFC3339)
+		}
+	case "token_expiry":
+		if !a.TokenExpiry.IsZero() {
+			return a.TokenExpiry.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// set updates a string account setting by config key
+func (a *Account) set(name, value string) {
+	switch name {
+	case "access_token":
+		a.AccessToken = value
+	case "refresh_token":
+		a.RefreshToken = value
+	case "token_url":
+		a.TokenURL = value
+	case "client_id":
+		a.ClientID = value
+	case "client_secret":
+		a.ClientSecret = value
+	case "audience":
+		a.Audience = value
+	case "user_id":
+		a.UserID = value
+	case "email":
+		a.Email = value
+	case "username":
+		a.Username = value
+	}
+}
+
+func (a *Account) empty() bool {
+	return a.AccessToken == "" && a.UserID == "" && a.Email == "" && a.Username == ""
+}
+
+// accountName picks the name a login is saved under: the username, falling
+// back to the email and user ID
+func accountName(userID, email, username string) string {
+	for _, name := range []string{username, email, userID} {
+		if name != "" {
+			return name
+		}
+	}
+	return DefaultProfile
+}
+
+// fileConfig is the on-disk layout of config.yaml. Settings that are not
+// profile specific (timeouts, retries, ...) stay at the top level.
+type fileConfig struct {
+	CurrentContext string                 `yaml:"current_context,omitempty"`
+	Contexts       map[string]*Profile    `yaml:"contexts,omitempty"`
+	Settings       map[string]interface{} `yaml:",inline"`
+
+	// secretMoves are accounts migrated from legacy profile credentials
+	// whose stored secrets move over when the file is saved
+	secretMoves []secretMove
+}
+
+// secretMove moves the credential store secrets of a profile to an account
+type secretMove struct {
+	profile, account string
+}
+
+// legacyProfileKeys were top-level keys before profiles were introduced
+var legacyProfileKeys = []string{"api_url", "access_token", "user_id", "email", "username", "last_login", "output"}
+
+var configFile *fileConfig
+
+// ConfigFilePath returns the config file in use: the --config file when
+// given, otherwise ~/.aphelion/config.yaml
+func ConfigFilePath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(home, ".aphelion", "config.yaml"), nil
+}
+
+func loadFile() (*fileConfig, error) {
+	file := &fileConfig{}
+
+	path, err := ConfigFilePath()
+	if err != nil {
+		return file, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		return file, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return &fileConfig{}, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	file.migrateLegacy()
+	file.migrateAccounts()
+	return file, nil
+}
+
+// migrateLegacy moves top-level credentials from configs written before
+// profiles existed into the default profile. Those configs always contain
+// access_token, which tells them apart from top-level settings written with
+// 'aphelion config set --global'. The file is rewritten in the new layout the
+// next time it is saved.
+func (f *fileConfig) migrateLegacy() {
+	if len(f.Contexts) > 0 {
+		return
+	}
+	if _, ok := f.Settings["access_token"]; !ok {
+		return
+	}
+
+	legacy := map[string]interface{}{}
+	for _, key := range legacyProfileKeys {
+		if value, ok := f.Settings[key]; ok {
+			legacy[key] = value
+			delete(f.Settings, key)
+		}
+	}
+	if len(legacy) == 0 {
+		return
+	}
+
+	profile := &Profile{}
+	if data, err := yaml.Marshal(legacy); err == nil {
+		yaml.Unmarshal(data, profile)
+	}
+
+	f.Contexts = map[string]*Profile{DefaultProfile: profile}
+	if f.CurrentContext == "" {
+		f.CurrentContext = DefaultProfile
+	}
+}
+
+// migrateAccounts moves the credentials of profiles written before accounts
+// existed into an account named after the user. Secrets kept in a credential
+// store are moved when the file is saved; until then they are read from
+// their old place.
+func (f *fileConfig) migrateAccounts() {
+	for name, p := range f.Contexts {
+		if p == nil || p.Legacy.empty() {
+			continue
+		}
+
+		account := p.Legacy
+		accountName := accountName(account.UserID, account.Email, account.Username)
+		if p.Accounts == nil {
+			p.Accounts = map[string]*Account{}
+		}
+		if _, ok := p.Accounts[accountName]; !ok {
+			p.Accounts[accountName] = &account
+			f.secretMoves = append(f.secretMoves, secretMove{profile: name, account: accountName})
+		}
+		if p.CurrentAccount == "" {
+			p.CurrentAccount = accountName
+		}
+		p.Legacy = Account{}
+	}
+}
+
+// movedFrom returns the account secrets of profile/account are still kept
+// under, which differs for accounts migrated since the file was last saved
+func (f *fileConfig) movedFrom(profile, account string) string {
+	for _, move := range f.secretMoves {
+		if move.profile == profile && move.account == account {
+			return ""
+		}
+	}
+	return account
+}
+
+// dropSecretMove forgets a pending secret move, for accounts that are removed
+// before their secrets were moved
+func (f *fileConfig) dropSecretMove(profile, account string) {
+	moves := f.secretMoves[:0]
+	for _, move := range f.secretMoves {
+		if move.profile != profile || move.account != account {
+			moves = append(moves, move)
+		}
+	}
+	f.secretMoves = moves
+}
+
+func (f *fileConfig) save() error {
+	f.moveSecrets()
+
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+
+	return nil
+}
+
+// account returns the named account of a profile, creating both when create
+// is set. The empty name addresses the profile's legacy credentials.
+func (f *fileConfig) account(profile, name string, create bool) *Account {
+	p := f.profile(profile, create)
+	if p == nil {
+		return nil
+	}
+	if name == "" {
+		return &p.Legacy
+	}
+	if a, ok := p.Accounts[name]; ok && a != nil {
+		return a
+	}
+	if !create {
+		return nil
+	}
+
+	if p.Accounts == nil {
+		p.Accounts = map[string]*Account{}
+	}
+	a := &Account{}
+	p.Accounts[name] = a
+	return a
+}
+
+// profile returns the named profile, creating it when create is set
+func (f *fileConfig) profile(name string, create bool) *Profile {
+	if p, ok := f.Contexts[name]; ok && p != nil {
+		return p
+	}
+	if !create {
+		return nil
+	}
+
+	if f.Contexts == nil {
+		f.Contexts = map[string]*Profile{}
+	}
+	p := &Profile{}
+	f.Contexts[name] = p
+	return p
+}
+
+func getFile() *fileConfig {
+	if configFile == nil {
+		InitConfig()
+	}
+	return configFile
+}
+
+// activeProfileName resolves the profile for this invocation: --profile or
+// APHELION_PROFILE, then current_context from the config file, then default
+func activeProfileName(file *fileConfig) string {
+	if name := viper.GetString("profile"); name != "" {
+		return name
+	}
+	if file.CurrentContext != "" {
+		return file.CurrentContext
+	}
+	return DefaultProfile
+}
+
+// activeAccountName resolves the account for this invocation: --account or
+// APHELION_ACCOUNT, then the profile's current account
+func activeAccountName(p *Profile) string {
+	if name := viper.GetString("account"); name != "" {
+		return name
+	}
+	if p != nil {
+		return p.CurrentAccount
+	}
+	return ""
+}
+
+// GetProfileName returns the name of the active profile
+func GetProfileName() string {
+	return GetConfig().Profile
+}
+
+// GetCurrentContext returns the profile selected in the config file
+func GetCurrentContext() string {
+	if name := getFile().CurrentContext; name != "" {
+		return name
+	}
+	return DefaultProfile
+}
+
+// ListProfiles returns the names of all profiles, sorted
+func ListProfiles() []string {
+	file := getFile()
+	names := make([]string, 0, len(file.Contexts))
+	for name := range file.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetProfile returns a copy of the named profile
+func GetProfile(name string) (Profile, bool) {
+	p := getFile().profile(name, false)
+	if p == nil {
+		return Profile{}, false
+	}
+	return *p, true
+}
+
+// UseProfile makes name the current context in the config file
+func UseProfile(name string) error {
+	file := getFile()
+	if file.profile(name, false) == nil {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	file.CurrentContext = name
+	return file.save()
+}
+
+// SetProfile creates or updates the named profile. Empty fields in settings
+// leave the existing values unchanged.
+func SetProfile(name string, settings Profile) error {
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	file := getFile()
+	p := file.profile(name, true)
+	if settings.APIUrl != "" {
+		p.APIUrl = settings.APIUrl
+	}
+	if settings.Output != "" {
+		p.Output = settings.Output
+	}
+
+	return file.save()
+}
+
+// DeleteProfile removes the named profile along with its credentials
+func DeleteProfile(name string) error {
+	file := getFile()
+	if file.profile(name, false) == nil {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	deleteSecrets(name, "")
+	for account := range file.Contexts[name].Accounts {
+		deleteSecrets(name, account)
+	}
+	delete(file.Contexts, name)
+	if file.CurrentContext == name {
+		file.CurrentContext = ""
+	}
+	return file.save()
+}
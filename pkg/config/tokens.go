@@ -0,0 +1,159 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TokenEnv supplies an access token, such as a personal access token, for
+// non-interactive use. It overrides the stored login.
+const TokenEnv = "APHELION_TOKEN"
+
+// Service account credentials for non-interactive use. A client secret from
+// the environment is not saved, so it must stay set for token renewal.
+const (
+	ClientIDEnv     = "APHELION_CLIENT_ID"
+	ClientSecretEnv = "APHELION_CLIENT_SECRET"
+)
+
+// TokenSet is an access token together with what is needed to renew it
+// once it expires. Tokens from the password login have no refresh token;
+// service accounts renew theirs with the client secret.
+type TokenSet struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Audience     string
+}
+
+// CanRefresh reports whether the access token can be renewed
+func (t TokenSet) CanRefresh() bool {
+	return (t.RefreshToken != "" || t.IsClientCredentials()) && t.TokenURL != ""
+}
+
+// IsClientCredentials reports whether the tokens belong to a service account
+// that obtains them with the client credentials grant
+func (t TokenSet) IsClientCredentials() bool {
+	return t.ClientSecret != ""
+}
+
+// ExpiresWithin reports whether the access token expires in less than d.
+// Tokens without a known expiry never do.
+func (t TokenSet) ExpiresWithin(d time.Duration) bool {
+	return !t.Expiry.IsZero() && time.Until(t.Expiry) < d
+}
+
+func (c *Config) setTokens(tokens TokenSet) {
+	c.AccessToken = tokens.AccessToken
+	c.RefreshToken = tokens.RefreshToken
+	c.TokenExpiry = tokens.Expiry
+	c.TokenURL = tokens.TokenURL
+	c.ClientID = tokens.ClientID
+	c.ClientSecret = tokens.ClientSecret
+	c.Audience = tokens.Audience
+	c.Service = tokens.IsClientCredentials()
+
+	// The credential store must not overwrite what was just set
+	for _, key := range secretKeys {
+		loadedSecrets[key] = true
+	}
+}
+
+// SetAuthTokens saves the tokens from a login along with the user they
+// belong to. The login is saved as the account named by --account, or else
+// after the user, and becomes the profile's current account; logging in as
+// another user adds an account rather than replacing the current one.
+func SetAuthTokens(tokens TokenSet, userID, email, username string) error {
+	name := viper.GetString("account")
+	if name == "" {
+		name = accountName(userID, email, username)
+	}
+
+	config := GetConfig()
+	config.Account = name
+	getFile().profile(config.Profile, true).CurrentAccount = name
+	config.setTokens(tokens)
+	config.UserID = userID
+	config.Email = email
+	config.Username = username
+	config.LastLogin = time.Now()
+
+	return SaveConfig()
+}
+
+// UpdateTokens saves renewed tokens for the logged in user
+func UpdateTokens(tokens TokenSet) error {
+	GetConfig().setTokens(tokens)
+	return SaveConfig()
+}
+
+// GetTokenSet returns the active account's tokens. An access token given
+// through the environment is used as is and never refreshed, since renewing
+// it would replace the stored login rather than the token in use.
+func GetTokenSet() TokenSet {
+	config := GetConfig()
+	tokens := TokenSet{AccessToken: GetAccessToken()}
+
+	if _, source := resolveKey(getFile(), config.Profile, mustKey("access_token")); source == SourceEnv || source == SourceFlag {
+		return tokens
+	}
+
+	tokens.RefreshToken = GetRefreshToken()
+	tokens.Expiry = config.TokenExpiry
+	tokens.TokenURL = config.TokenURL
+	tokens.ClientID = config.ClientID
+	if config.Service {
+		tokens.ClientSecret = GetClientSecret()
+		tokens.Audience = config.Audience
+	}
+	return tokens
+}
+
+// GetRefreshToken returns the refresh token, reading it from the credential
+// store on first use
+func GetRefreshToken() string {
+	return loadSecret("refresh_token", &GetConfig().RefreshToken)
+}
+
+// GetClientSecret returns the client secret of a service account, reading it
+// from the credential store on first use
+func GetClientSecret() string {
+	return loadSecret("client_secret", &GetConfig().ClientSecret)
+}
+
+// GetTokenExpiry returns when the access token expires, or the zero time if
+// it is unknown
+func GetTokenExpiry() time.Time {
+	return GetConfig().TokenExpiry
+}
+
+// AccessTokenSource reports where the access token in use comes from, with a
+// detail naming the environment variable, credential store backend or config
+// file. The source is SourceDefault when there is no token.
+func AccessTokenSource() (Source, string) {
+	key := mustKey("access_token")
+	if _, name, ok := key.lookupEnv(); ok {
+		return SourceEnv, name
+	}
+
+	value, source, err := Value(key.Name)
+	if err != nil || value == "" {
+		return SourceDefault, ""
+	}
+
+	switch source {
+	case SourceStore:
+		if store, err := GetCredentialStore(); err == nil {
+			return source, store.Name()
+		}
+	case SourceProfile, SourceFile:
+		if path, err := ConfigFilePath(); err == nil {
+			return source, path
+		}
+	}
+	return source, ""
+}